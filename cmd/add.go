@@ -1,83 +1,448 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/auth"
 	"github.com/solo-io/thetool/pkg/config"
 	"github.com/solo-io/thetool/pkg/downloader"
 	"github.com/solo-io/thetool/pkg/feature"
 	"github.com/spf13/cobra"
 )
 
-// AddCmd adds a Gloo feature repository at specific commit hash
+// AddCmd adds a Gloo feature repository at specific branch, tag or commit,
+// or a local directory
 // It downloads and parses features.json and adds the features
 // listed in the file
 func AddCmd() *cobra.Command {
-	var repoURL string
-	var commitHash string
-	var manifest string
+	repo := feature.Repository{}
+	var writeManifest string
+	var fromRegistry, registryLocation string
 	var verbose bool
 
 	cmd := &cobra.Command{
-		Use:     "add",
-		Aliases: []string{"update"},
-		Short:   "add or update a Gloo feature repository",
-		Long:    "add or update a Gloo feature repository and all the features in the repository",
+		Use:   "add",
+		Short: "add or update a Gloo feature repository",
+		Long: `add or update a Gloo feature repository and all the features in the repository
+The commit can be a branch, a tag or a (short) commit hash; it is resolved to
+the full commit hash and the branch or tag is tracked by 'thetool update'.
+Features are identified by the repository alias and their name, for example
+gloo/aws_lambda, so repositories can provide features with the same name.
+Repositories without a manifest can be added with --discover, which finds
+Gloo plugins and Envoy filters by scanning the repository.
+In repositories that hold more than features, --subdir is the directory with
+the features; the manifest and the feature directories are relative to it.
+The manifest and the subdirectory are kept when the repository is added again
+or updated.
+A local directory, given as a path or file:// URL, is used in place with any
+uncommitted changes; it doesn't need a commit.
+With --from-registry, the repository of a feature found with 'thetool search'
+is added with the commit and settings recommended by the registry, and the
+feature is enabled.`,
 		Run: func(c *cobra.Command, args []string) {
-			err := runAdd(verbose, repoURL, commitHash, manifest)
+			if fromRegistry != "" {
+				if repo.URL != "" {
+					fmt.Println("--repository can't be used with --from-registry")
+					return
+				}
+				if err := runAddFromRegistry(verbose, registryLocation, fromRegistry, repo); err != nil {
+					fmt.Println("unable to add the feature from the registry:", auth.Redact(err.Error()))
+				}
+				return
+			}
+			if repo.URL == "" {
+				fmt.Println("please specify the repository with --repository or a feature with --from-registry")
+				return
+			}
+			if repo.Ref == "" && !downloader.IsLocal(repo.URL) {
+				fmt.Println("please specify a branch, tag or commit with --commit")
+				return
+			}
+			if writeManifest != "" && !repo.Discover {
+				fmt.Println("--write-manifest can only be used with --discover")
+				return
+			}
+			err := runAdd(verbose, repo)
 			if err != nil {
-				fmt.Println("unable to add/update the repository", err)
+				fmt.Println("unable to add/update the repository", auth.Redact(err.Error()))
+				return
+			}
+			if writeManifest != "" {
+				if err := saveDiscoveredManifest(repo, writeManifest); err != nil {
+					fmt.Println("unable to write the manifest", err)
+				}
 			}
 		},
 	}
 
 	flags := cmd.Flags()
-	flags.StringVarP(&repoURL, "repository", "r", "", "repository URL")
-	flags.StringVarP(&commitHash, "commit", "c", "", "commit hash")
-	flags.StringVarP(&commitHash, "manifest", "m", feature.FeaturesFileName, "manifest file describe the Gloo features in the repository")
+	flags.StringVarP(&repo.URL, "repository", "r", "", "repository URL")
+	flags.StringVarP(&repo.Ref, "commit", "c", "", "branch, tag or commit hash")
+	flags.StringVarP(&repo.Manifest, "manifest", "m", "", "manifest file describing the Gloo features in the repository (default "+feature.FeaturesFileName+", or the one the repository was added with)")
+	flags.StringVar(&repo.Subdir, "subdir", "", "directory of the repository with the features, or . for the whole repository (default the one the repository was added with)")
+	flags.StringVarP(&repo.Alias, "alias", "a", "", "short name of the repository used to qualify its features; defaults to the repository name")
+	flags.StringVar(&repo.SHA256, "sha256", "", "expected sha256 checksum of the archive for repositories downloaded as an archive; trusted on first use if empty")
+	flags.BoolVar(&repo.Discover, "discover", false, "find the features by scanning the repository instead of reading the manifest")
+	flags.StringVar(&writeManifest, "write-manifest", "", "with --discover, also write the discovered manifest to this file")
+	flags.StringVar(&fromRegistry, "from-registry", "", "add the repository of this feature of the registry, by name or qualified name")
+	flags.StringVar(&registryLocation, "registry", "", "URL or path of the feature registry index")
 	flags.BoolVarP(&verbose, "verbose", "v", false, "verbose logging")
 
-	cmd.MarkFlagRequired("repository")
-	cmd.MarkFlagRequired("commit")
-
 	return cmd
 }
 
-func runAdd(verbose bool, repo, hash, manifest string) error {
+// runAddFromRegistry adds the repository of the feature at the commit the
+// registry recommends, unless another one is given, and enables the feature
+func runAddFromRegistry(verbose bool, location, name string, r feature.Repository) error {
+	ix, err := loadRegistry(location)
+	if err != nil {
+		return err
+	}
+	m, err := ix.Find(name)
+	if err != nil {
+		return err
+	}
+	r.URL = m.Repository.URL
+	if r.Ref == "" {
+		r.Ref = m.Repository.Commit
+	}
+	if r.Alias == "" {
+		r.Alias = m.Repository.Alias
+	}
+	if r.Manifest == "" {
+		r.Manifest = m.Repository.Manifest
+	}
+	if r.Subdir == "" {
+		r.Subdir = m.Repository.Subdir
+	}
+	if r.SHA256 == "" && r.Ref == m.Repository.Commit {
+		r.SHA256 = m.Repository.SHA256
+	}
+	if err := addProviding(verbose, r, m.Feature.Name); err != nil {
+		return err
+	}
+	url, err := addedURL(r.URL)
+	if err != nil {
+		return err
+	}
+	return enableAdded(url, m.Feature.Name)
+}
+
+// enableAdded enables the feature of the repository that was just added,
+// with the features it requires
+func enableAdded(repoURL, name string) error {
+	store := &feature.FileFeatureStore{Filename: feature.FeaturesFileName}
+	features, err := store.List()
+	if err != nil {
+		return errors.Wrap(err, "unable to load features")
+	}
+	for _, f := range features {
+		if f.Repository != repoURL || f.Name != name {
+			continue
+		}
+		changed, err := feature.Enable(features, f.ID())
+		if err != nil {
+			return err
+		}
+		if len(changed) == 0 {
+			fmt.Printf("Feature %s is enabled\n", f.ID())
+			return nil
+		}
+		if err := store.UpdateAll(features); err != nil {
+			return errors.Wrap(err, "unable to update features")
+		}
+		fmt.Printf("Features enabled: %s\n", strings.Join(changed, ", "))
+		return nil
+	}
+	return fmt.Errorf("repository %s does not provide the feature %s listed by the registry", repoURL, name)
+}
+
+// addedURL is the URL the repository is added with: the absolute path of
+// local repositories, or the URL as given
+func addedURL(repo string) (string, error) {
+	if !downloader.IsLocal(repo) {
+		return repo, nil
+	}
+	p, err := downloader.LocalPath(repo)
+	return p, errors.Wrapf(err, "invalid local repository %s", repo)
+}
+
+// runAdd downloads the repository at the requested ref and adds or updates
+// its features
+func runAdd(verbose bool, r feature.Repository) error {
+	return addProviding(verbose, r, "")
+}
+
+// addProviding adds the repository like runAdd, but only if it provides the
+// named feature, unless the name is empty
+func addProviding(verbose bool, r feature.Repository, name string) error {
+	repo, ref := r.URL, r.Ref
 	repoStore := &feature.FileRepoStore{Filename: feature.ReposFileName}
-	if !downloader.SupportedURL(repo) {
-		return fmt.Errorf("unsupported repository URL %s\nShould either end in '.git' or be HTTP/HTTPS", repo)
+	if _, err := downloader.Lookup(repo); err != nil {
+		return err
+	}
+	if downloader.IsLocal(repo) {
+		// local repositories are used in place at their current content
+		p, err := addedURL(repo)
+		if err != nil {
+			return err
+		}
+		r.URL, r.Ref = p, ""
+	}
+	r, err := keepSettings(repoStore, r)
+	if err != nil {
+		return err
+	}
+	if downloader.IsLocal(r.URL) {
+		alias, err := repoAlias(repoStore, r)
+		if err != nil {
+			return err
+		}
+		r.Alias = alias
+		return addRepo(verbose, repoStore, r, name)
 	}
 
-	err := downloader.Download(repo, hash, config.WorkDir, verbose)
+	alias, err := repoAlias(repoStore, r)
+	if err != nil {
+		return err
+	}
+	hash, err := downloader.ResolveRef(repo, ref)
 	if err != nil {
-		return errors.Wrapf(err, "unable to download repository %s", repo, err)
+		return errors.Wrapf(err, "unable to resolve %s in repository %s", ref, repo)
 	}
+	r.Alias, r.Commit = alias, hash
+	return addRepo(verbose, repoStore, r, name)
+}
 
-	mf, err := feature.LoadManifest(filepath.Join(config.WorkDir, downloader.RepoDir(repo), manifest))
+// addRepo downloads the repository at its commit and adds or updates its
+// features. If name isn't empty, the repository must provide that feature.
+func addRepo(verbose bool, repoStore *feature.FileRepoStore, r feature.Repository, name string) error {
+	req, err := repoRequest(repoStore, r)
+	if err != nil {
+		return err
+	}
+	outcomes, err := fetchAll([]fetchRequest{req}, 1, verbose)
 	if err != nil {
-		return errors.Wrapf(err, "unable to load features manifest for repository %s", repo)
+		return err
 	}
-	if len(mf) == 0 {
+	return addFetched(verbose, repoStore, r, req.SHA256, outcomes[0].Result, name)
+}
+
+// repoRequest is the request to fetch the repository at its commit
+func repoRequest(repoStore *feature.FileRepoStore, r feature.Repository) (fetchRequest, error) {
+	expected, err := expectedSHA256(repoStore, r)
+	if err != nil {
+		return fetchRequest{}, err
+	}
+	return fetchRequest{Name: r.ID(), URL: r.URL, Revision: r.Commit, SHA256: expected}, nil
+}
+
+// addFetched adds or updates the features of a fetched repository, and the
+// repositories its manifest includes. If name isn't empty, nothing is added
+// unless the repository provides that feature.
+func addFetched(verbose bool, repoStore *feature.FileRepoStore, r feature.Repository, expected string, res *downloader.Result, name string) error {
+	repo, ref, hash := r.URL, r.Ref, r.Commit
+	r.SHA256 = res.SHA256
+	if expected == "" && res.SHA256 != "" {
+		fmt.Printf("Trusting the archive of %s with sha256 %s on first use\n", repo, res.SHA256)
+	}
+	if downloader.IsLocal(repo) {
+		// the content hash is the revision of local repositories
+		hash = res.Revision
+		r.Commit = hash
+	}
+	r.Root = res.Root
+
+	c, err := repoFeatures(verbose, repoStore, r)
+	if err != nil {
+		return err
+	}
+	if len(c.Features) == 0 {
 		return fmt.Errorf("not adding repository %s as it does not contain any Gloo features", repo)
 	}
+	if name != "" && !provides(c.Features, repo, name) {
+		return fmt.Errorf("not adding repository %s as it does not provide the feature %s", repo, name)
+	}
+	for _, o := range c.Overridden {
+		fmt.Println("Note:", o)
+	}
 
-	features := feature.ToFeatures(repo, hash, mf)
 	featureStore := &feature.FileFeatureStore{Filename: feature.FeaturesFileName}
-	err = featureStore.AddOrUpdateAll(features)
-	if err != nil {
-		return errors.Wrapf(err, "unable to add features found in repo %s", repo)
+	for _, u := range append([]string{repo}, includedURLs(c.Included)...) {
+		if err := replaceFeatures(featureStore, u, c.Features); err != nil {
+			return errors.Wrapf(err, "unable to add features found in repo %s", u)
+		}
+	}
+	if err := removeStaleIncludes(repoStore, featureStore, repo, c.Included); err != nil {
+		return err
+	}
+	for _, included := range c.Included {
+		if _, err := repoStore.AddOrUpdate(included); err != nil {
+			return errors.Wrapf(err, "unable to save repo %s", included.URL)
+		}
+		fmt.Printf("Included repository %s with %s\n", included.URL, describeRef(included.Ref, included.Commit))
 	}
-	updated, err := repoStore.AddOrUpdate(feature.Repository{URL: repo, Commit: hash})
+	updated, err := repoStore.AddOrUpdate(r)
 	if err != nil {
 		return errors.Wrapf(err, "unable to save repo %s", repo)
 	}
 	if updated {
-		fmt.Printf("Updated repository %s to commit hash %s\n", repo, hash)
+		fmt.Printf("Updated repository %s to %s\n", repo, describeRef(ref, hash))
 	} else {
-		fmt.Printf("Added repository %s with commit hash %s\n", repo, hash)
+		fmt.Printf("Added repository %s with %s\n", repo, describeRef(ref, hash))
 	}
 	return nil
 }
+
+// provides checks if the features include the named feature of the repository
+func provides(features []feature.Feature, repo, name string) bool {
+	for _, f := range features {
+		if f.Repository == repo && f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedSHA256 is the checksum the archive of the repository must have:
+// the one given for it, or else the one recorded when the same commit was
+// downloaded before
+func expectedSHA256(repoStore *feature.FileRepoStore, r feature.Repository) (string, error) {
+	if r.SHA256 != "" {
+		return r.SHA256, nil
+	}
+	repos, err := repoStore.List()
+	if err != nil {
+		return "", err
+	}
+	for _, added := range repos {
+		if added.URL == r.URL && added.Commit == r.Commit {
+			return added.SHA256, nil
+		}
+	}
+	return "", nil
+}
+
+// saveDiscoveredManifest writes the manifest discovered for the repository
+func saveDiscoveredManifest(r feature.Repository, filename string) error {
+	root := downloader.SourceDir(config.WorkDir, r.URL)
+	repos, err := (&feature.FileRepoStore{Filename: feature.ReposFileName}).List()
+	if err != nil {
+		return err
+	}
+	for _, added := range repos {
+		if added.URL == repositoryArg(r.URL) {
+			root = filepath.Join(sourceDir(added.Root, added.URL), filepath.FromSlash(added.Subdir))
+		}
+	}
+	mf, err := feature.Discover(root)
+	if err != nil {
+		return err
+	}
+	if err := feature.SaveManifest(filename, mf); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote the discovered manifest to %s\n", filename)
+	return nil
+}
+
+// repoFeatures loads the manifest of the downloaded repository with the
+// manifests it includes, or proposes one from the features found in the
+// repository in discovery mode
+func repoFeatures(verbose bool, repoStore *feature.FileRepoStore, r feature.Repository) (*feature.Composition, error) {
+	repoPath := sourceDir(r.Root, r.URL)
+	if !r.Discover {
+		r.Root = repoPath
+		c, err := feature.Compose(r, includeResolver(verbose, repoStore, r))
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("repository %s has no features manifest %s; use --manifest to give its path or --discover to find its features", r.URL, r.ManifestFile())
+		}
+		return c, errors.Wrapf(err, "unable to load features manifest for repository %s", r.URL)
+	}
+
+	mf, err := feature.Discover(filepath.Join(repoPath, filepath.FromSlash(r.Subdir)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to discover features in repository %s", r.URL)
+	}
+	b, err := json.MarshalIndent(mf, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Discovered %d features in repository %s with the manifest:\n%s\n", len(mf), r.URL, b)
+	features := feature.ToFeatures(r.URL, r.Commit, mf)
+	feature.InSubdir(features, r.Subdir)
+	for i := range features {
+		features[i].Alias = r.Alias
+		features[i].Root = r.Root
+	}
+	return &feature.Composition{Features: features}, nil
+}
+
+func describeRef(ref, hash string) string {
+	if ref == "" && strings.HasPrefix(hash, "sha256:") {
+		return "content hash " + hash
+	}
+	if ref == hash {
+		return "commit hash " + hash
+	}
+	return fmt.Sprintf("%s (commit hash %s)", ref, hash)
+}
+
+// keepSettings fills in the manifest and the subdirectory the repository
+// was added with, unless others are given
+func keepSettings(store *feature.FileRepoStore, r feature.Repository) (feature.Repository, error) {
+	existing, err := store.List()
+	if err != nil {
+		return r, err
+	}
+	for _, e := range existing {
+		if e.URL != r.URL {
+			continue
+		}
+		if r.Manifest == "" {
+			r.Manifest = e.Manifest
+		}
+		if r.Subdir == "" {
+			r.Subdir = e.Subdir
+		}
+	}
+	subdir, err := feature.CleanSubdir(r.Subdir)
+	if err != nil {
+		return r, err
+	}
+	r.Subdir = subdir
+	return r, nil
+}
+
+// repoAlias returns the alias for the repository. It keeps the alias of a
+// repository that is already added unless a new one is given, and makes
+// sure no other repository uses the same alias.
+func repoAlias(store *feature.FileRepoStore, r feature.Repository) (string, error) {
+	existing, err := store.List()
+	if err != nil {
+		return "", err
+	}
+	alias := r.Alias
+	for _, e := range existing {
+		if e.URL == r.URL && alias == "" {
+			alias = e.ID()
+		}
+	}
+	if alias == "" {
+		alias = feature.DefaultAlias(r.URL)
+	}
+	if strings.Contains(alias, "/") {
+		return "", fmt.Errorf("repository alias %s can't contain '/'", alias)
+	}
+	for _, e := range existing {
+		if e.URL != r.URL && e.ID() == alias {
+			return "", fmt.Errorf("repository %s already uses the alias %s; please choose another one with --alias", e.URL, alias)
+		}
+	}
+	return alias, nil
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/addon"
 	"gopkg.in/AlecAivazis/survey.v1"
 )
 
@@ -16,10 +17,10 @@ const (
 type EnableDisable struct {
 }
 
-func (e EnableDisable) configure(a *Addon) {
+func (e EnableDisable) configure(a *addon.Addon) {
 	defaultSelection := statusEnable
 	if a.Configuration != nil {
-		v, ok := a.Configuration[keyEnable]
+		v, ok := a.Configuration[addon.KeyEnable]
 		if ok {
 			if v.(bool) {
 				defaultSelection = statusEnable
@@ -52,7 +53,7 @@ func (e EnableDisable) configure(a *Addon) {
 	if a.Configuration == nil {
 		a.Configuration = make(map[string]interface{})
 	}
-	a.Configuration[keyEnable] = answer.Status == statusEnable
+	a.Configuration[addon.KeyEnable] = answer.Status == statusEnable
 }
 
 const (
@@ -73,9 +74,9 @@ var (
 
 type MetricsConfigurator struct{}
 
-func (m MetricsConfigurator) configure(a *Addon) {
+func (m MetricsConfigurator) configure(a *addon.Addon) {
 	newStatus := askStatus(metricsStatus, a, []string{disable, statsd, prometheus, all})
-	a.Configuration[keyStatus] = newStatus
+	a.Configuration[addon.KeyStatus] = newStatus
 	switch newStatus {
 	case statsd:
 		askStatsdAddress(a)
@@ -99,16 +100,16 @@ var (
 
 type TracingConfigurator struct{}
 
-func (t TracingConfigurator) configure(a *Addon) {
+func (t TracingConfigurator) configure(a *addon.Addon) {
 	newStatus := askStatus(tracingStatus, a, []string{disable, "configure", "install"})
-	a.Configuration[keyStatus] = newStatus
+	a.Configuration[addon.KeyStatus] = newStatus
 	if newStatus == "configure" {
 		askJaegerAddress(a)
 	}
 }
 
-func askStatus(m map[string]string, a *Addon, optionOrder []string) string {
-	defaultSelection, ok := m[a.Configuration[keyStatus].(string)]
+func askStatus(m map[string]string, a *addon.Addon, optionOrder []string) string {
+	defaultSelection, ok := m[a.Configuration[addon.KeyStatus].(string)]
 	if !ok {
 		defaultSelection = disable
 	}
@@ -127,7 +128,7 @@ func askStatus(m map[string]string, a *Addon, optionOrder []string) string {
 	err := survey.AskOne(prompt, &answer, survey.Required)
 	if err != nil {
 		fmt.Println("Unable to configure addon", a.Name, err)
-		return a.Configuration[keyStatus].(string)
+		return a.Configuration[addon.KeyStatus].(string)
 	}
 
 	for k, v := range m {
@@ -138,7 +139,7 @@ func askStatus(m map[string]string, a *Addon, optionOrder []string) string {
 	return disable
 }
 
-func askEnableServiceMonitor(a *Addon) {
+func askEnableServiceMonitor(a *addon.Addon) {
 	prompt := &survey.Select{
 		Message: "Setup service monitor with Prometheus Operator",
 		Options: []string{"yes", "no"},
@@ -157,7 +158,7 @@ func askEnableServiceMonitor(a *Addon) {
 	}
 }
 
-func askMonitoringNamespace(a *Addon) {
+func askMonitoringNamespace(a *addon.Addon) {
 	prompt := &survey.Input{
 		Message: "Please enter the namespace for monitoring services",
 		Default: "monitoring",
@@ -174,7 +175,7 @@ func askMonitoringNamespace(a *Addon) {
 	a.Configuration["namespace"] = answer
 }
 
-func askStatsdAddress(a *Addon) {
+func askStatsdAddress(a *addon.Addon) {
 	var questions = []*survey.Question{
 		{
 			Name: "host",
@@ -205,7 +206,7 @@ func askStatsdAddress(a *Addon) {
 	a.Configuration["statsd_port"] = strconv.Itoa(answers.Port)
 }
 
-func askJaegerAddress(a *Addon) {
+func askJaegerAddress(a *addon.Addon) {
 	var questions = []*survey.Question{
 		{
 			Name: "host",
@@ -250,3 +251,20 @@ func validatePort(val interface{}) error {
 	}
 	return nil
 }
+
+type configurator interface {
+	configure(*addon.Addon)
+}
+
+// configuratorFor returns the configurator asking the settings of the addon
+func configuratorFor(a *addon.Addon) (configurator, bool) {
+	switch {
+	case a.Name == addon.Metrics:
+		return MetricsConfigurator{}, true
+	case a.Name == addon.OpenTracing:
+		return TracingConfigurator{}, true
+	case a.IsGlooAddon():
+		return EnableDisable{}, true
+	}
+	return nil, false
+}
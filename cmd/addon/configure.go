@@ -3,6 +3,7 @@ package addon
 import (
 	"fmt"
 
+	"github.com/solo-io/thetool/pkg/addon"
 	"github.com/spf13/cobra"
 	"gopkg.in/AlecAivazis/survey.v1"
 )
@@ -11,7 +12,7 @@ func configureCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:       "configure",
 		Short:     "configure add-ons",
-		ValidArgs: addonNames(),
+		ValidArgs: addon.Names(),
 		Args:      cobra.OnlyValidArgs,
 		Run: func(c *cobra.Command, args []string) {
 			if len(args) == 1 {
@@ -30,20 +31,20 @@ func configureCmd() *cobra.Command {
 }
 
 func runConfigure(name string) {
-	addons, err := List()
+	addons, err := addon.List()
 	if err != nil {
 		fmt.Println("Unable to get list of addons.")
 		return
 	}
 	for _, a := range addons {
 		if a.Name == name {
-			configurator, ok := configuratorMap[a.Name]
+			configurator, ok := configuratorFor(a)
 			if !ok {
 				fmt.Println("No configurator set for", a.Name)
 				return
 			}
 			configurator.configure(a)
-			if err := save(addonFilename, addons); err != nil {
+			if err := addon.Save(addons); err != nil {
 				fmt.Println("Unable to update list of addons", err)
 			}
 			return
@@ -58,7 +59,7 @@ func askAddonName() (string, error) {
 			Name: "name",
 			Prompt: &survey.Select{
 				Message: "Select addon to configure",
-				Options: addonNames(),
+				Options: addon.Names(),
 			},
 			Validate: survey.Required,
 		},
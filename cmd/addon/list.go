@@ -3,13 +3,16 @@ package addon
 import (
 	"fmt"
 
+	"github.com/solo-io/thetool/pkg/addon"
+	"github.com/solo-io/thetool/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
 func listCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "list",
-		Short: "list addons",
+		Use:         "list",
+		Short:       "list addons",
+		Annotations: map[string]string{workspace.ReadOnlyAnnotation: "true"},
 		Run: func(c *cobra.Command, args []string) {
 			runList()
 		},
@@ -18,7 +21,7 @@ func listCmd() *cobra.Command {
 }
 
 func runList() {
-	addons, err := load(addonFilename)
+	addons, err := addon.List()
 	if err != nil {
 		fmt.Printf("Unable to load addons %q\n", err)
 		return
@@ -3,6 +3,7 @@ package addon
 import (
 	"fmt"
 
+	"github.com/solo-io/thetool/pkg/addon"
 	"github.com/spf13/cobra"
 )
 
@@ -35,7 +36,7 @@ func markConfigOnlyCmd() *cobra.Command {
 }
 
 func runMarkInstall(addonName string, configOnly bool) {
-	addons, err := load(addonFilename)
+	addons, err := addon.List()
 	if err != nil {
 		fmt.Println("Unable to load list of addons", err)
 		return
@@ -46,7 +47,7 @@ func runMarkInstall(addonName string, configOnly bool) {
 		}
 	}
 
-	if err := save(addonFilename, addons); err != nil {
+	if err := addon.Save(addons); err != nil {
 		fmt.Println("Unable to update list of addons", err)
 	}
 }
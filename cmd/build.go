@@ -8,11 +8,13 @@ import (
 
 	"github.com/solo-io/thetool/pkg/component"
 	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/lock"
 	"github.com/spf13/cobra"
 )
 
 func BuildCmd() *cobra.Command {
 	var jobs int
+	var locked bool
 	config := component.BuilderConfig{}
 	components := component.Components()
 	cmd := &cobra.Command{
@@ -29,7 +31,7 @@ Supported components are:
 				return fmt.Errorf("please specify a build target")
 			}
 			target := strings.ToLower(args[0])
-			return runBuild(jobs, config, target)
+			return runBuild(jobs, locked, config, target)
 		},
 	}
 	// don't use cache by default on mac
@@ -43,11 +45,17 @@ Supported components are:
 	flags.StringVarP(&config.DockerUser, "docker-user", "u", "", "Docker user for publishing images")
 	flags.StringVar(&config.SSHKeyFile, "ssh-key", "", "file containg SSH key for git to use with private repositories")
 	flags.IntVarP(&jobs, "jobs", "j", 1, "number of jobs to run simultaneously")
+	flags.BoolVar(&locked, "locked", false, "refuse to build if the workspace doesn't match "+lock.Filename)
 	return cmd
 }
 
-func runBuild(jobs int, buildConfig component.BuilderConfig, target string) error {
+func runBuild(jobs int, locked bool, buildConfig component.BuilderConfig, target string) error {
 	var err error
+	if locked {
+		if err := checkLock(); err != nil {
+			return err
+		}
+	}
 	buildConfig.Config, err = config.Load(config.ConfigFile)
 	if err != nil {
 		fmt.Printf("Unable to load configuration from %s: %q\n", config.ConfigFile, err)
@@ -63,6 +71,11 @@ func runBuild(jobs int, buildConfig component.BuilderConfig, target string) erro
 	if err != nil {
 		return err
 	}
+	if !buildConfig.DryRun {
+		if err := fetchMissing(); err != nil {
+			return err
+		}
+	}
 	if buildConfig.PublishImage {
 		fmt.Printf("Building and publishing with %d features\n", len(buildConfig.Enabled))
 	} else {
@@ -87,7 +100,9 @@ func runBuild(jobs int, buildConfig component.BuilderConfig, target string) erro
 			wg.Add(1)
 			jobCh <- func() {
 				defer wg.Done()
-				b.Builder(buildConfig)
+				if err := b.Builder(buildConfig); err != nil {
+					fmt.Println(err)
+				}
 			}
 		}
 	}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/cache"
+	"github.com/spf13/cobra"
+)
+
+// CacheCmd manages the sources shared by all workspaces
+func CacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "manage the repositories cached for all workspaces",
+		Long: `manage the repositories cached for all workspaces
+Git repositories are mirrored and archives downloaded once into the cache and
+copied into the workspaces from there. The cache is in thetool/sources in the
+cache directory of the user, or in $` + cache.DirEnv + `.`,
+	}
+	cmd.AddCommand(cacheListCmd(), cachePruneCmd())
+	return cmd
+}
+
+func cacheListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:         "ls",
+		Short:       "list the cached repositories, the least recently used first",
+		Annotations: readOnly(),
+		RunE: func(c *cobra.Command, args []string) error {
+			return runCacheList()
+		},
+	}
+}
+
+func cachePruneCmd() *cobra.Command {
+	var unused time.Duration
+	var all bool
+	cmd := &cobra.Command{
+		Use:         "prune",
+		Short:       "remove cached repositories that were not used recently",
+		Annotations: readOnly(),
+		RunE: func(c *cobra.Command, args []string) error {
+			if all {
+				unused = 0
+			}
+			return runCachePrune(unused)
+		},
+	}
+	cmd.Flags().DurationVar(&unused, "unused", 30*24*time.Hour, "remove repositories not used for this long")
+	cmd.Flags().BoolVar(&all, "all", false, "remove all cached repositories")
+	return cmd
+}
+
+func runCacheList() error {
+	entries, err := cache.List()
+	if err != nil {
+		return errors.Wrap(err, "unable to list the cache")
+	}
+	if len(entries) == 0 {
+		fmt.Println("The cache is empty")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tREPOSITORY\tREVISION\tSIZE\tLAST USED")
+	var total int64
+	for _, e := range entries {
+		url := e.URL
+		if url == "" {
+			url = "(incomplete) " + e.Dir
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Kind, url, e.Revision, byteSize(e.Size), e.LastUsed.Format("2006-01-02 15:04"))
+		total += e.Size
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	dir, _ := cache.Dir()
+	fmt.Printf("\n%d repositories, %s in %s\n", len(entries), byteSize(total), dir)
+	return nil
+}
+
+func runCachePrune(unused time.Duration) error {
+	removed, err := cache.Prune(unused)
+	var total int64
+	for _, e := range removed {
+		switch {
+		case e.Revision != "":
+			fmt.Println("Removed", e.Kind, e.URL, e.Revision)
+		case e.URL != "":
+			fmt.Println("Removed", e.Kind, e.URL)
+		default:
+			fmt.Println("Removed", e.Dir)
+		}
+		total += e.Size
+	}
+	if err != nil {
+		return errors.Wrap(err, "unable to prune the cache")
+	}
+	fmt.Printf("Removed %d repositories, %s\n", len(removed), byteSize(total))
+	return nil
+}
+
+func byteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -24,6 +24,7 @@ func ConfigureCmd() *cobra.Command {
 	flags.StringVar(&conf.GlooRepo, "gloo-repo", "", "Gloo git repository")
 	flags.StringVarP(&conf.DockerUser, "user", "u", "", "default Docker user")
 	flags.StringVar(&conf.EnvoyBuilderHash, "envoy-builder-hash", "", "hash for envoy build container")
+	flags.StringVar(&conf.Registry, "registry", "", "URL or path of the feature registry index")
 
 	return cmd
 }
@@ -56,6 +57,9 @@ func runConfigure(c *config.Config) {
 	if c.GlooRepo != "" {
 		existing.GlooRepo = c.GlooRepo
 	}
+	if c.Registry != "" {
+		existing.Registry = c.Registry
+	}
 
 	if err := existing.Save(config.ConfigFile); err != nil {
 		fmt.Printf("unable to save the configuration to %s: %q\n", config.ConfigFile, err)
@@ -73,4 +77,5 @@ func show(c *config.Config) {
 	fmt.Printf("%-20s: %s\n", "Envoy Common Hash", c.EnvoyCommonHash)
 	fmt.Printf("%-20s: %s\n", "Gloo Hash", c.GlooHash)
 	fmt.Printf("%-20s: %s\n", "Gloo Repo", c.GlooRepo)
+	fmt.Printf("%-20s: %s\n", "Registry", c.Registry)
 }
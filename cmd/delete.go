@@ -13,7 +13,8 @@ func DeleteCmd() *cobra.Command {
 		Use:   "delete",
 		Short: "remove a Gloo feature repository",
 		RunE: func(c *cobra.Command, args []string) error {
-			return runDelete(repoURL)
+			c.SilenceUsage = true
+			return runDelete(repositoryArg(repoURL))
 		},
 	}
 	cmd.Flags().StringVarP(&repoURL, "repository", "r", "", "URL of the repository to remove")
@@ -22,6 +23,16 @@ func DeleteCmd() *cobra.Command {
 }
 
 func runDelete(repoURL string) error {
+	repoStore := &feature.FileRepoStore{Filename: feature.ReposFileName}
+	repos, err := repoStore.List()
+	if err != nil {
+		return err
+	}
+	for _, r := range repos {
+		if r.URL == repoURL && r.IncludedBy != "" {
+			return fmt.Errorf("repository %s is included by %s; remove it from the manifest of that repository", repoURL, r.IncludedBy)
+		}
+	}
 	// remove features for the repo
 	featureStore := &feature.FileFeatureStore{Filename: feature.FeaturesFileName}
 	if err := featureStore.RemoveForRepo(repoURL); err != nil {
@@ -29,9 +40,13 @@ func runDelete(repoURL string) error {
 		return nil
 	}
 	// remove the repo
-	repoStore := &feature.FileRepoStore{Filename: feature.ReposFileName}
 	if err := repoStore.Remove(repoURL); err != nil {
 		fmt.Printf("Uable to remove repository %s: %q\n", repoURL, err)
+		return nil
+	}
+	// and the repositories it includes
+	if err := removeStaleIncludes(repoStore, featureStore, repoURL, nil); err != nil {
+		fmt.Printf("Unable to remove the repositories included by %s: %q\n", repoURL, err)
 	}
 
 	return nil
@@ -10,7 +10,7 @@ import (
 	"regexp"
 	"text/template"
 
-	"github.com/solo-io/thetool/cmd/addon"
+	"github.com/solo-io/thetool/pkg/addon"
 	"github.com/solo-io/thetool/pkg/downloader"
 	"github.com/solo-io/thetool/pkg/feature"
 	"github.com/solo-io/thetool/pkg/util"
@@ -90,7 +90,7 @@ func runDeployK8S(verbose, dryRun bool, dockerUser, imageTag string, options k8s
 			return errors.Wrap(err, "unable to download Gloo")
 		}
 	}
-	helmDir := filepath.Join(config.WorkDir, downloader.RepoDir(conf.GlooRepo), "install", "helm")
+	helmDir := filepath.Join(glooDir(conf.GlooRepo), "install", "helm")
 	templateFile := filepath.Join(helmDir, "bootstrap.yaml")
 	if !options.resume {
 		enabled, err := loadEnabledFeatures()
@@ -172,9 +172,14 @@ func runDeployK8S(verbose, dryRun bool, dockerUser, imageTag string, options k8s
 	return nil
 }
 
+// glooDir is where Gloo is downloaded to in the work directory, or the
+// checkout itself for a local Gloo repository
+func glooDir(glooRepo string) string {
+	return downloader.SourceDir(config.WorkDir, glooRepo)
+}
+
 func glooDownloaded(glooRepo string) bool {
-	glooDir := filepath.Join(config.WorkDir, downloader.RepoDir(glooRepo))
-	_, err := os.Stat(glooDir)
+	_, err := os.Stat(glooDir(glooRepo))
 	return err == nil
 }
 
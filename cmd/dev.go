@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/solo-io/thetool/pkg/component"
+	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/feature"
+	"github.com/solo-io/thetool/pkg/watch"
+	"github.com/solo-io/thetool/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+// DevCmd watches the enabled features and rebuilds what changed
+func DevCmd() *cobra.Command {
+	var interval, debounce time.Duration
+	config := component.BuilderConfig{}
+	cmd := &cobra.Command{
+		Use:   "dev",
+		Short: "rebuild gloo and envoy when enabled features change",
+		Long: `
+Watch the source of the enabled features and rebuild the components that
+use the changed files: changes in the Gloo directory of a feature rebuild
+gloo and changes in its Envoy directory rebuild envoy. Edit features from
+local directories (see add) to work on them in place. When the Gloo
+repository is a local directory (see configure --gloo-repo), changes in it
+rebuild gloo as well. Enabling or disabling features while dev runs rebuilds
+the components they are part of.`,
+		// the workspace is only locked while building, so features can be
+		// enabled from another terminal
+		Annotations: readOnly(),
+		RunE: func(c *cobra.Command, args []string) error {
+			return runDev(interval, debounce, config)
+		},
+	}
+	enableCache := runtime.GOOS != "darwin"
+	flags := cmd.Flags()
+	flags.BoolVarP(&config.Verbose, "verbose", "v", false, "show verbose build log")
+	flags.BoolVarP(&config.DryRun, "dry-run", "d", false, "dry run; only generate build files")
+	flags.BoolVar(&config.UseCache, "cache", enableCache, "use cache for builds")
+	flags.BoolVarP(&config.PublishImage, "publish", "p", false, "publish Docker images to registry")
+	flags.StringVarP(&config.ImageTag, "image-tag", "t", "", "tag for Docker images; uses auto-generated hash if empty")
+	flags.StringVarP(&config.DockerUser, "docker-user", "u", "", "Docker user for images")
+	flags.StringVar(&config.SSHKeyFile, "ssh-key", "", "file containg SSH key for git to use with private repositories")
+	flags.DurationVar(&interval, "interval", 500*time.Millisecond, "time between checks for changes")
+	flags.DurationVar(&debounce, "debounce", time.Second, "time without changes before rebuilding")
+	return cmd
+}
+
+func runDev(interval, debounce time.Duration, buildConfig component.BuilderConfig) error {
+	enabled, err := loadEnabledFeatures()
+	if err != nil {
+		return err
+	}
+	if len(enabled) == 0 {
+		fmt.Println("No features are enabled; please enable the features to work on")
+		return nil
+	}
+	targets := devTargets(enabled)
+	dirs := devDirs(targets)
+	w, err := watch.New(dirs, interval, debounce)
+	if err != nil {
+		return err
+	}
+	// builds stop their container on Ctrl+C; dev stops once they are done
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	fmt.Printf("Watching %d directories of %d features; press Ctrl+C to stop\n", len(watch.Dirs(targets)), len(enabled))
+	for {
+		changed, err := w.Wait(stop)
+		if err != nil || changed == nil {
+			return err
+		}
+		// features may have changed as well as their source
+		reloaded, err := loadEnabledFeatures()
+		if err != nil {
+			fmt.Printf("%s unable to load features: %v\n", timestamp(), err)
+			continue
+		}
+		previous := targets
+		targets = devTargets(reloaded)
+		affected := watch.Affected(targets, changed)
+		if !reflect.DeepEqual(reloaded, enabled) {
+			// the features enabled or disabled change what is built
+			affected = components(append(previous, targets...))
+		}
+		enabled = reloaded
+		for _, name := range affected {
+			select {
+			case <-stop:
+				return nil
+			default:
+				rebuild(name, buildConfig, enabled)
+			}
+		}
+		if d := devDirs(targets); !reflect.DeepEqual(d, dirs) {
+			dirs = d
+			if w, err = watch.New(dirs, interval, debounce); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// devTargets maps the source directories of the enabled features and of a
+// local Gloo checkout to the components built from them
+func devTargets(enabled []feature.Feature) []watch.Target {
+	glooRepo := ""
+	if conf, err := config.Load(config.ConfigFile); err == nil {
+		glooRepo = conf.GlooRepo
+	}
+	return watch.Targets(config.WorkDir, glooRepo, enabled)
+}
+
+// devDirs are the directories to watch for the targets, and the features
+// file to notice features being enabled or disabled
+func devDirs(targets []watch.Target) []string {
+	return append(watch.Dirs(targets), feature.FeaturesFileName)
+}
+
+// components returns the components built from the targets, in their order
+func components(targets []watch.Target) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, t := range targets {
+		if !seen[t.Component] {
+			seen[t.Component] = true
+			names = append(names, t.Component)
+		}
+	}
+	return names
+}
+
+// rebuild builds one component and prints a single line with the result
+func rebuild(name string, buildConfig component.BuilderConfig, enabled []feature.Feature) {
+	b, ok := component.Find(name)
+	if !ok {
+		return
+	}
+	start := time.Now()
+	err := func() error {
+		l, err := workspace.Acquire(".")
+		if err != nil {
+			return err
+		}
+		defer l.Release()
+		conf, err := config.Load(config.ConfigFile)
+		if err != nil {
+			return err
+		}
+		buildConfig.Config = conf
+		buildConfig.Enabled = enabled
+		if buildConfig.DockerUser == "" {
+			buildConfig.DockerUser = conf.DockerUser
+		}
+		if buildConfig.DockerUser == "" && buildConfig.PublishImage {
+			return fmt.Errorf("need Docker user ID to publish images")
+		}
+		if buildConfig.ImageTag == "" {
+			buildConfig.ImageTag = featuresHash(enabled)
+		}
+		return b.Builder(buildConfig)
+	}()
+	elapsed := time.Since(start).Round(time.Second)
+	if err != nil {
+		// the first line of the error is enough to know what went wrong
+		msg := strings.SplitN(err.Error(), "\n", 2)[0]
+		fmt.Printf("%s %-5s FAILED after %v: %s\n", timestamp(), name, elapsed, msg)
+		return
+	}
+	fmt.Printf("%s %-5s ok in %v (image tag %s)\n", timestamp(), name, elapsed, buildConfig.ImageTag)
+}
+
+func timestamp() string {
+	return time.Now().Format("15:04:05")
+}
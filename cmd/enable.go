@@ -2,59 +2,98 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/solo-io/thetool/pkg/feature"
 	"github.com/spf13/cobra"
 )
 
-// EnableCmd enables a given feature
+// EnableCmd enables the given features
 func EnableCmd() *cobra.Command {
-	var featureName string
+	var featureNames []string
+	var tags []string
 
 	cmd := &cobra.Command{
-		Use:   "enable",
-		Short: "enable a feature from feature list",
+		Use:   "enable [feature names]",
+		Short: "enable features from feature list",
+		Long: `enable features from feature list by name or by tag
+Features required by the features are enabled as well. If any of the
+features or tags can't be found, no feature is changed.`,
 		RunE: func(c *cobra.Command, args []string) error {
-			return runChangeStatus(featureName, true)
+			return runChangeStatus(append(featureNames, args...), tags, true, false)
 		},
 	}
-	cmd.Flags().StringVarP(&featureName, "name", "n", "", "name of feature to enable")
-	cmd.MarkFlagRequired("name")
+	cmd.Flags().StringSliceVarP(&featureNames, "name", "n", nil, "name of feature to enable")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "enable all features with this tag")
 	return cmd
 }
 
-// DisableCmd disables a give feature
+// DisableCmd disables the given features
 func DisableCmd() *cobra.Command {
-	var featureName string
+	var featureNames []string
+	var tags []string
+	var cascade bool
 
 	cmd := &cobra.Command{
-		Use:   "disable",
-		Short: "disable a feature from feature list",
+		Use:   "disable [feature names]",
+		Short: "disable features from feature list",
+		Long: `disable features from feature list by name or by tag
+A feature that is required by another enabled feature can only be disabled
+together with the features requiring it, using the cascade flag. If any of
+the features or tags can't be found, no feature is changed.`,
 		RunE: func(c *cobra.Command, args []string) error {
-			return runChangeStatus(featureName, false)
+			return runChangeStatus(append(featureNames, args...), tags, false, cascade)
 		},
 	}
-	cmd.Flags().StringVarP(&featureName, "name", "n", "", "name of feature to disable")
-	cmd.MarkFlagRequired("name")
+	cmd.Flags().StringSliceVarP(&featureNames, "name", "n", nil, "name of feature to disable")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "disable all features with this tag")
+	cmd.Flags().BoolVar(&cascade, "cascade", false, "also disable enabled features that require these features")
 	return cmd
 }
 
-func runChangeStatus(featureName string, status bool) error {
+func runChangeStatus(featureNames, tags []string, status, cascade bool) error {
+	if len(featureNames) == 0 && len(tags) == 0 {
+		return fmt.Errorf("please specify the features by name or by tag")
+	}
 	store := &feature.FileFeatureStore{Filename: feature.FeaturesFileName}
 	existing, err := store.List()
 	if err != nil {
 		fmt.Printf("Unable to load feature list: %q\n", err)
 		return nil
 	}
-	for i, f := range existing {
-		if featureName == f.Name {
-			existing[i].Enabled = status
-			if err := store.Update(existing[i]); err != nil {
-				fmt.Printf("Unable to update feature %s\n", featureName)
-			}
+	if len(tags) != 0 {
+		tagged, err := feature.SelectByTag(existing, tags...)
+		if err != nil {
+			fmt.Println(err)
 			return nil
 		}
+		featureNames = append(featureNames, tagged...)
+	}
+	var changed []string
+	if status {
+		changed, err = feature.Enable(existing, featureNames...)
+	} else {
+		changed, err = feature.Disable(existing, cascade, featureNames...)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	if len(changed) == 0 {
+		fmt.Printf("Features are already %s\n", statusName(status))
+		return nil
+	}
+	if err := store.UpdateAll(existing); err != nil {
+		fmt.Printf("Unable to update features: %q\n", err)
+		return nil
 	}
-	fmt.Printf("Unable to find feature %s\n", featureName)
+	fmt.Printf("Features %s: %s\n", statusName(status), strings.Join(changed, ", "))
 	return nil
 }
+
+func statusName(status bool) string {
+	if status {
+		return "enabled"
+	}
+	return "disabled"
+}
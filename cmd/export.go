@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/addon"
+	"github.com/solo-io/thetool/pkg/bundle"
+	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/feature"
+	"github.com/spf13/cobra"
+)
+
+// ExportCmd writes the workspace to a single bundle file
+func ExportCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "export the workspace to a single file",
+		Long: `export the configuration, the repositories, the features and the addon
+configuration of the workspace to a single JSON or YAML file, depending on
+the extension of the file. Use 'thetool init --from' to recreate the
+workspace from the file.`,
+		Annotations: readOnly(),
+		RunE: func(c *cobra.Command, args []string) error {
+			return runExport(output)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "workspace.json", "file to write the workspace to; .yaml or .yml for YAML")
+	return cmd
+}
+
+func runExport(output string) error {
+	conf, err := config.Load(config.ConfigFile)
+	if err != nil {
+		return errors.Wrapf(err, "unable to load configuration from %s", config.ConfigFile)
+	}
+	repoStore := &feature.FileRepoStore{Filename: feature.ReposFileName}
+	repos, err := repoStore.List()
+	if err != nil {
+		return errors.Wrap(err, "unable to load repositories")
+	}
+	features, err := loadFeatures()
+	if err != nil {
+		return errors.Wrap(err, "unable to load features")
+	}
+	addons, err := addon.List()
+	if err != nil {
+		return errors.Wrap(err, "unable to load addons")
+	}
+	if err := bundle.New(conf, repos, features, addons).Save(output); err != nil {
+		return errors.Wrapf(err, "unable to write %s", output)
+	}
+	fmt.Printf("Exported %d repositories and %d features to %s\n", len(repos), len(features), output)
+	return nil
+}
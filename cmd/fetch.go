@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-colorable"
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/auth"
+	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/downloader"
+	"github.com/solo-io/thetool/pkg/feature"
+	"github.com/spf13/cobra"
+)
+
+// defaultFetchJobs is how many repositories are fetched at the same time
+// by commands without a --jobs flag
+const defaultFetchJobs = 4
+
+// FetchCmd downloads all repositories of the workspace
+func FetchCmd() *cobra.Command {
+	var jobs int
+	var verbose bool
+	cmd := &cobra.Command{
+		Use:   "fetch",
+		Short: "download Gloo and the feature repositories",
+		Long: `download Gloo and the feature repositories at their recorded commits
+Repositories are fetched in parallel; the failures are reported together once
+all of them are done. Fetch a workspace up front, for example after checking
+it out without its repositories directory, so builds don't have to.`,
+		RunE: func(c *cobra.Command, args []string) error {
+			c.SilenceUsage = true
+			return runFetch(jobs, verbose)
+		},
+	}
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", defaultFetchJobs, "number of repositories to fetch simultaneously")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "show the output of git")
+	return cmd
+}
+
+func runFetch(jobs int, verbose bool) error {
+	requests, err := workspaceRequests(false)
+	if err != nil {
+		return err
+	}
+	if _, err := fetchAll(requests, jobs, verbose); err != nil {
+		return err
+	}
+	fmt.Printf("Fetched %d repositories\n", len(requests))
+	return nil
+}
+
+// fetchMissing fetches the repositories whose source is not in the
+// workspace, for example because the workspace was checked out without
+// them
+func fetchMissing() error {
+	requests, err := workspaceRequests(true)
+	if err != nil || len(requests) == 0 {
+		return err
+	}
+	_, err = fetchAll(requests, defaultFetchJobs, false)
+	return err
+}
+
+// workspaceRequests lists Gloo and the feature repositories of the
+// workspace, or only those that are missing
+func workspaceRequests(missingOnly bool) ([]fetchRequest, error) {
+	conf, err := config.Load(config.ConfigFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load configuration from %s", config.ConfigFile)
+	}
+	repos, err := (&feature.FileRepoStore{Filename: feature.ReposFileName}).List()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load repositories")
+	}
+	missing := func(dir string) bool {
+		_, err := os.Stat(dir)
+		return os.IsNotExist(err)
+	}
+
+	var requests []fetchRequest
+	if !missingOnly || missing(glooDir(conf.GlooRepo)) {
+		requests = append(requests, fetchRequest{Name: "gloo", URL: conf.GlooRepo, Revision: conf.GlooHash})
+	}
+	for _, r := range repos {
+		if missingOnly && (downloader.IsLocal(r.URL) || !missing(sourceDir(r.Root, r.URL))) {
+			continue
+		}
+		requests = append(requests, fetchRequest{Name: r.ID(), URL: r.URL, Revision: r.Commit, SHA256: r.SHA256})
+	}
+	return requests, nil
+}
+
+type fetchRequest struct {
+	// Name is shown in the progress and the report
+	Name     string
+	URL      string
+	Revision string
+	SHA256   string
+}
+
+type fetchOutcome struct {
+	Result  *downloader.Result
+	Err     error
+	Elapsed time.Duration
+}
+
+// fetchAll fetches the repositories into the work directory with a pool of
+// workers and shows their progress. Requests for the same directory, such
+// as Gloo when it is also a feature repository, are fetched one after the
+// other by the same worker and must agree on the revision. The outcomes are
+// in the order of the requests; the error reports every failure.
+func fetchAll(requests []fetchRequest, jobs int, verbose bool) ([]fetchOutcome, error) {
+	groups := make(map[string][]int)
+	var dirs []string
+	for i, r := range requests {
+		dir := downloader.RepoDir(r.URL)
+		if same, ok := groups[dir]; ok {
+			if other := requests[same[0]]; !sameRevision(other.Revision, r.Revision) {
+				return nil, fmt.Errorf("%s and %s are both downloaded to %s but at different revisions, %s and %s",
+					other.Name, r.Name, dir, other.Revision, r.Revision)
+			}
+		} else {
+			dirs = append(dirs, dir)
+		}
+		groups[dir] = append(groups[dir], i)
+	}
+
+	outcomes := make([]fetchOutcome, len(requests))
+	display := newFetchDisplay(requests, verbose)
+
+	jobCh := make(chan func(), len(dirs))
+	if jobs < 1 {
+		jobs = 1
+	}
+	for w := 0; w < jobs; w++ {
+		go worker(jobCh)
+	}
+	var wg sync.WaitGroup
+	for _, dir := range dirs {
+		group := groups[dir]
+		wg.Add(1)
+		jobCh <- func() {
+			defer wg.Done()
+			for _, i := range group {
+				i, r := i, requests[i]
+				display.start(i)
+				start := time.Now()
+				opts := downloader.Options{
+					Verbose:  verbose,
+					SHA256:   r.SHA256,
+					Progress: func(p downloader.Progress) { display.progress(i, p) },
+				}
+				res, err := downloader.Fetch(r.URL, r.Revision, config.WorkDir, opts)
+				outcomes[i] = fetchOutcome{Result: res, Err: err, Elapsed: time.Since(start)}
+				display.finish(i, err, outcomes[i].Elapsed)
+			}
+		}
+	}
+	close(jobCh)
+	wg.Wait()
+	display.stop()
+
+	var failed []string
+	for i, o := range outcomes {
+		if o.Err != nil {
+			msg := strings.Replace(auth.Redact(o.Err.Error()), "\n", "\n    ", -1)
+			failed = append(failed, fmt.Sprintf("  %s (%s): %s", requests[i].Name, auth.Redact(requests[i].URL), msg))
+		}
+	}
+	switch {
+	case len(failed) == 0:
+		return outcomes, nil
+	case len(requests) == 1:
+		return outcomes, fmt.Errorf("unable to download repository %s: %s", auth.Redact(requests[0].URL), auth.Redact(outcomes[0].Err.Error()))
+	}
+	return outcomes, fmt.Errorf("unable to fetch %d of %d repositories:\n%s", len(failed), len(requests), strings.Join(failed, "\n"))
+}
+
+// sameRevision checks if two revisions are the same, counting an
+// abbreviated commit hash as the same as the full one
+func sameRevision(a, b string) bool {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	return a == b || (downloader.IsCommit(a) && len(b) >= 7 && strings.HasPrefix(a, b))
+}
+
+// fetchDisplay shows a line with the status of every repository, updated
+// in place on a terminal. Elsewhere, and with the verbose output of git,
+// it prints a line when a fetch starts and when it ends.
+type fetchDisplay struct {
+	mu       sync.Mutex
+	out      io.Writer
+	live     bool
+	requests []fetchRequest
+	started  []time.Time
+	amount   []string
+	finished []string
+	drawn    int
+	done     chan struct{}
+	stopped  sync.WaitGroup
+}
+
+func newFetchDisplay(requests []fetchRequest, verbose bool) *fetchDisplay {
+	d := &fetchDisplay{
+		out:      os.Stdout,
+		live:     !verbose && isatty.IsTerminal(os.Stdout.Fd()),
+		requests: requests,
+		started:  make([]time.Time, len(requests)),
+		amount:   make([]string, len(requests)),
+		finished: make([]string, len(requests)),
+		done:     make(chan struct{}),
+	}
+	if d.live {
+		// moves the cursor on Windows consoles too
+		d.out = colorable.NewColorableStdout()
+		d.stopped.Add(1)
+		go d.refresh()
+	}
+	return d
+}
+
+func (d *fetchDisplay) refresh() {
+	defer d.stopped.Done()
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.mu.Lock()
+			d.draw()
+			d.mu.Unlock()
+		case <-d.done:
+			d.mu.Lock()
+			d.draw()
+			d.mu.Unlock()
+			return
+		}
+	}
+}
+
+// draw rewrites the lines drawn before
+func (d *fetchDisplay) draw() {
+	if d.drawn > 0 {
+		fmt.Fprintf(d.out, "\x1b[%dA", d.drawn)
+	}
+	width := 0
+	for _, r := range d.requests {
+		if len(r.Name) > width {
+			width = len(r.Name)
+		}
+	}
+	for i, r := range d.requests {
+		status := d.finished[i]
+		switch {
+		case status != "":
+		case d.started[i].IsZero():
+			status = "waiting"
+		default:
+			status = fmt.Sprintf("fetching %s%v", d.amount[i], time.Since(d.started[i]).Round(time.Second))
+		}
+		fmt.Fprintf(d.out, "\r\x1b[K%-*s  %s\n", width, r.Name, status)
+	}
+	d.drawn = len(d.requests)
+}
+
+func (d *fetchDisplay) start(i int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.started[i] = time.Now()
+	if !d.live {
+		fmt.Fprintf(d.out, "Fetching %s...\n", auth.Redact(d.requests[i].URL))
+	}
+}
+
+func (d *fetchDisplay) progress(i int, p downloader.Progress) {
+	if !d.live {
+		return
+	}
+	var s string
+	switch {
+	case p.Unit == "bytes" && p.Total > 0:
+		s = fmt.Sprintf("%s of %s", byteSize(p.Done), byteSize(p.Total))
+	case p.Unit == "bytes":
+		s = byteSize(p.Done)
+	case p.Total > 0:
+		s = fmt.Sprintf("%d%% (%d/%d %s)", p.Done*100/p.Total, p.Done, p.Total, p.Unit)
+	default:
+		s = fmt.Sprintf("%d %s", p.Done, p.Unit)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.amount[i] = s + ", "
+}
+
+func (d *fetchDisplay) finish(i int, err error, elapsed time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	elapsed = elapsed.Round(100 * time.Millisecond)
+	if err != nil {
+		d.finished[i] = fmt.Sprintf("FAILED after %v", elapsed)
+	} else {
+		d.finished[i] = fmt.Sprintf("done in %v", elapsed)
+	}
+	if !d.live {
+		if err != nil {
+			fmt.Fprintf(d.out, "Unable to fetch %s after %v\n", auth.Redact(d.requests[i].URL), elapsed)
+		} else {
+			fmt.Fprintf(d.out, "Fetched %s in %v\n", auth.Redact(d.requests[i].URL), elapsed)
+		}
+	}
+}
+
+// stop draws the final status
+func (d *fetchDisplay) stop() {
+	close(d.done)
+	d.stopped.Wait()
+}
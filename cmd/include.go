@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/downloader"
+	"github.com/solo-io/thetool/pkg/feature"
+)
+
+// includeResolver downloads the repositories included by the manifests of
+// the parent repository. They belong to the parent: a repository that was
+// added on its own or is included by another repository can't be included.
+func includeResolver(verbose bool, repoStore *feature.FileRepoStore, parent feature.Repository) feature.Resolver {
+	return func(inc feature.Include) (feature.Repository, error) {
+		r := feature.Repository{
+			URL:        inc.Repository,
+			Alias:      inc.Alias,
+			Ref:        inc.Commit,
+			Manifest:   inc.Manifest,
+			SHA256:     inc.SHA256,
+			IncludedBy: parent.URL,
+		}
+		if downloader.IsLocal(r.URL) {
+			return r, fmt.Errorf("local directories can't be included")
+		}
+		if _, err := downloader.Lookup(r.URL); err != nil {
+			return r, err
+		}
+		if r.Ref == "" {
+			return r, fmt.Errorf("no commit for the included repository")
+		}
+		existing, err := repoStore.List()
+		if err != nil {
+			return r, err
+		}
+		for _, e := range existing {
+			switch {
+			case e.URL != r.URL || e.IncludedBy == parent.URL:
+			case e.IncludedBy != "":
+				return r, fmt.Errorf("repository %s is already included by %s", r.URL, e.IncludedBy)
+			default:
+				return r, fmt.Errorf("repository %s is already added; delete it to include it", r.URL)
+			}
+		}
+		if r.Alias, err = repoAlias(repoStore, r); err != nil {
+			return r, err
+		}
+		if r.Commit, err = downloader.ResolveRef(r.URL, r.Ref); err != nil {
+			return r, errors.Wrapf(err, "unable to resolve %s", r.Ref)
+		}
+		req, err := repoRequest(repoStore, r)
+		if err != nil {
+			return r, err
+		}
+		outcomes, err := fetchAll([]fetchRequest{req}, 1, verbose)
+		if err != nil {
+			return r, err
+		}
+		r.Root, r.SHA256 = outcomes[0].Result.Root, outcomes[0].Result.SHA256
+		return r, nil
+	}
+}
+
+func includedURLs(included []feature.Repository) []string {
+	urls := make([]string, len(included))
+	for i, r := range included {
+		urls[i] = r.URL
+	}
+	return urls
+}
+
+// replaceFeatures replaces the features of the repository with its features
+// among the given ones
+func replaceFeatures(store *feature.FileFeatureStore, repoURL string, features []feature.Feature) error {
+	var own []feature.Feature
+	for _, f := range features {
+		if f.Repository == repoURL {
+			own = append(own, f)
+		}
+	}
+	if len(own) == 0 {
+		return store.RemoveForRepo(repoURL)
+	}
+	return store.AddOrUpdateAll(own)
+}
+
+// removeStaleIncludes removes the repositories the manifests of the parent
+// repository no longer include, with their features
+func removeStaleIncludes(repoStore *feature.FileRepoStore, featureStore *feature.FileFeatureStore, parentURL string, included []feature.Repository) error {
+	repos, err := repoStore.List()
+	if err != nil {
+		return err
+	}
+	current := make(map[string]bool)
+	for _, r := range included {
+		current[r.URL] = true
+	}
+	for _, r := range repos {
+		if r.IncludedBy != parentURL || current[r.URL] {
+			continue
+		}
+		if err := featureStore.RemoveForRepo(r.URL); err != nil {
+			return errors.Wrapf(err, "unable to remove features for repository %s", r.URL)
+		}
+		if err := repoStore.Remove(r.URL); err != nil {
+			return errors.Wrapf(err, "unable to remove repository %s", r.URL)
+		}
+		fmt.Printf("Removed repository %s, which is no longer included by %s\n", r.URL, parentURL)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/solo-io/thetool/pkg/envoy"
+	"github.com/solo-io/thetool/pkg/feature"
+	"github.com/solo-io/thetool/pkg/gloo"
+	"github.com/spf13/cobra"
+)
+
+// InfoCmd shows everything known about a feature
+func InfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "info [feature name]",
+		Short: "show details of a feature",
+		Long: `show the description and other details from the manifest of a feature
+together with the Go package of its Gloo plugin, the Bazel repository and
+target of its Envoy filter and where its source is in the workspace.`,
+		Annotations: readOnly(),
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("please specify one feature")
+			}
+			runInfo(args[0])
+			return nil
+		},
+	}
+	return cmd
+}
+
+func runInfo(name string) {
+	features, err := loadFeatures()
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("Please add feature repository before looking at features")
+		} else {
+			fmt.Printf("Unable to load feature list: %q\n", err)
+		}
+		return
+	}
+	i, err := feature.Find(features, name)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	f := features[i]
+
+	// the Bazel repository name depends on the other enabled features
+	enabled := []feature.Feature{f}
+	for j, e := range features {
+		if e.Enabled && j != i {
+			enabled = append(enabled, e)
+		}
+	}
+	source := sourceDir(f.Root, f.Repository)
+
+	fmt.Println("Name:            ", f.Name)
+	fmt.Println("Qualified Name:  ", f.ID())
+	fmt.Println("Enabled:         ", f.Enabled)
+	printIfSet("Description:     ", f.Description)
+	printIfSet("Maintainers:     ", strings.Join(f.Maintainers, ", "))
+	printIfSet("License:         ", f.License)
+	printIfSet("Homepage:        ", f.Homepage)
+	printIfSet("Documentation:   ", f.Docs)
+	printIfSet("Tags:            ", strings.Join(f.Tags, ", "))
+	printIfSet("Requires:        ", strings.Join(f.Requires, ", "))
+	printIfSet("Conflicts:       ", strings.Join(f.Conflicts, ", "))
+	fmt.Println("Repository:      ", f.Repository)
+	fmt.Println("Revision:        ", f.Revision)
+	printIfSet("Provenance:      ", f.Provenance)
+	fmt.Println("Source:          ", source)
+	if f.GlooDir != "" {
+		fmt.Println("Gloo Directory:  ", filepath.Join(source, f.GlooDir))
+		fmt.Println("Gloo Package:    ", gloo.PluginPackage(f))
+	}
+	if f.EnvoyDir != "" {
+		fmt.Println("Envoy Directory: ", filepath.Join(source, f.EnvoyDir))
+		fmt.Println("Bazel Repository:", envoy.RepositoryName(f, enabled))
+		fmt.Println("Bazel Target:    ", envoy.Target(f, enabled))
+	}
+}
+
+func printIfSet(label, value string) {
+	if value != "" {
+		fmt.Println(label, value)
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/solo-io/thetool/cmd/addon"
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/addon"
+	"github.com/solo-io/thetool/pkg/bundle"
 	"github.com/solo-io/thetool/pkg/config"
 	"github.com/solo-io/thetool/pkg/feature"
+	"github.com/solo-io/thetool/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
@@ -14,13 +17,18 @@ import (
 func InitCmd() *cobra.Command {
 	var verbose bool
 	var noDefaults bool
+	var from string
 	conf := config.Config{}
 
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "intialize the tool",
+		Long: `intialize the tool
+With --from, the workspace is recreated from a file written by 'thetool export':
+the repositories are downloaded at their recorded commits and the features and
+addons are configured as they were exported.`,
 		Run: func(c *cobra.Command, args []string) {
-			runInit(verbose, noDefaults, conf)
+			runInit(verbose, noDefaults, conf, from)
 		},
 	}
 	flags := cmd.Flags()
@@ -33,10 +41,11 @@ func InitCmd() *cobra.Command {
 	flags.StringVarP(&conf.DockerUser, "user", "u", config.DockerUser, "default Docker user")
 
 	flags.BoolVar(&noDefaults, "no-defaults", false, "do not add default features")
+	flags.StringVar(&from, "from", "", "initialize from a workspace file created with 'thetool export'")
 	return cmd
 }
 
-func runInit(verbose, noDefaults bool, conf config.Config) {
+func runInit(verbose, noDefaults bool, conf config.Config, from string) {
 	fmt.Println("Initializing current directory...")
 	// check if this directory is already initialized
 	if _, err := os.Stat(feature.ReposFileName); err == nil {
@@ -44,9 +53,21 @@ func runInit(verbose, noDefaults bool, conf config.Config) {
 		return
 	}
 
-	// Let's save the configuration file that aren't changed via CLI args
-	conf.EnvoyBuilderHash = config.EnvoyBuilderHash
+	var b *bundle.Bundle
+	if from != "" {
+		var err error
+		b, err = bundle.Load(from)
+		if err != nil {
+			fmt.Printf("Unable to load workspace file %s: %q\n", from, err)
+			return
+		}
+		conf = *b.Config
+	} else {
+		// Let's save the configuration file that aren't changed via CLI args
+		conf.EnvoyBuilderHash = config.EnvoyBuilderHash
+	}
 
+	created := newWorkspaceFiles()
 	if err := conf.Save(config.ConfigFile); err != nil {
 		fmt.Printf("unable to save the configuration to %s: %q\n", config.ConfigFile, err)
 		return
@@ -76,13 +97,93 @@ func runInit(verbose, noDefaults bool, conf config.Config) {
 		fmt.Printf("Unable to initialize supporting addons file: %q\n", err)
 		return
 	}
-	if !noDefaults {
+	if b != nil {
+		fmt.Printf("Importing workspace from %s...\n", from)
+		if err := importBundle(verbose, b); err != nil {
+			fmt.Printf("Unable to import workspace from %s: %v\n", from, err)
+			created.remove()
+			return
+		}
+	} else if !noDefaults {
 		fmt.Println("Adding default repositories...")
 		// add the plugins in Gloo as default features
-		if err := runAdd(verbose, conf.GlooRepo, conf.GlooHash, "pkg/plugins/features.json"); err != nil {
+		glooRepo := feature.Repository{URL: conf.GlooRepo, Ref: conf.GlooHash, Manifest: "pkg/plugins/features.json"}
+		if err := runAdd(verbose, glooRepo); err != nil {
 			fmt.Printf("Error setting up default features: %q\n", err)
 			return
 		}
 	}
 	fmt.Println("Initialized.")
 }
+
+// workspaceFiles are the files and directories of the workspace that didn't
+// exist before init, so a failed import doesn't leave a workspace behind
+type workspaceFiles []string
+
+func newWorkspaceFiles() workspaceFiles {
+	var created workspaceFiles
+	for _, f := range []string{config.ConfigFile, feature.ReposFileName, feature.FeaturesFileName, addon.Filename, config.WorkDir} {
+		if _, err := os.Stat(f); os.IsNotExist(err) {
+			created = append(created, f)
+		}
+	}
+	return created
+}
+
+func (created workspaceFiles) remove() {
+	for _, f := range created {
+		os.RemoveAll(f)
+		os.Remove(f + workspace.BackupSuffix)
+	}
+	fmt.Println("Removed the partially initialized workspace")
+}
+
+// importBundle downloads the repositories of the bundle at their recorded
+// commits and restores the status of the features and the addons. Included
+// repositories are added with the repositories including them.
+func importBundle(verbose bool, b *bundle.Bundle) error {
+	repoStore := &feature.FileRepoStore{Filename: feature.ReposFileName}
+	var repos []feature.Repository
+	for _, r := range b.Repositories {
+		if r.IncludedBy == "" {
+			repos = append(repos, r)
+		}
+	}
+	requests := make([]fetchRequest, len(repos))
+	for i, r := range repos {
+		req, err := repoRequest(repoStore, r)
+		if err != nil {
+			return err
+		}
+		requests[i] = req
+	}
+	// download everything first, then add the repositories in order
+	outcomes, err := fetchAll(requests, defaultFetchJobs, verbose)
+	if err != nil {
+		return err
+	}
+	for i, r := range repos {
+		if err := addFetched(verbose, repoStore, r, requests[i].SHA256, outcomes[i].Result, ""); err != nil {
+			return err
+		}
+	}
+
+	featureStore := &feature.FileFeatureStore{Filename: feature.FeaturesFileName}
+	features, err := featureStore.List()
+	if err != nil {
+		return errors.Wrap(err, "unable to load features")
+	}
+	if err := b.Check(features); err != nil {
+		return err
+	}
+	b.Apply(features)
+	if err := featureStore.UpdateAll(features); err != nil {
+		return errors.Wrap(err, "unable to update features")
+	}
+	if len(b.Addons) != 0 {
+		if err := addon.Save(b.Addons); err != nil {
+			return errors.Wrap(err, "unable to save addons")
+		}
+	}
+	return nil
+}
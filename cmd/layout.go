@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/downloader"
+	"github.com/solo-io/thetool/pkg/feature"
+)
+
+// migrateLayout moves the repositories of workspaces created before the
+// work directory was keyed by host and path, from repositories/<name> to
+// where downloader.RepoDir expects them, and updates the roots recorded for
+// the repositories and their features. A directory several repositories
+// had the same name for is left alone; those repositories are fetched again
+// when they are needed.
+func migrateLayout() error {
+	if _, err := os.Stat(config.ConfigFile); err != nil {
+		// not in a workspace, or init hasn't created it yet
+		return nil
+	}
+	conf, err := config.Load(config.ConfigFile)
+	if err != nil {
+		return errors.Wrapf(err, "unable to load configuration from %s", config.ConfigFile)
+	}
+	repoStore := &feature.FileRepoStore{Filename: feature.ReposFileName}
+	repos, err := repoStore.List()
+	if err != nil {
+		return errors.Wrap(err, "unable to load repositories")
+	}
+
+	urls := []string{conf.GlooRepo}
+	for _, r := range repos {
+		if !downloader.IsLocal(r.URL) {
+			urls = append(urls, r.URL)
+		}
+	}
+	moved, err := downloader.MoveLegacyDirs(config.WorkDir, urls)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range repos {
+		if !moved[r.URL] {
+			continue
+		}
+		root, ok := downloader.RelocateRoot(r.Root, config.WorkDir, r.URL)
+		if !ok {
+			continue
+		}
+		r.Root = root
+		if _, err := repoStore.AddOrUpdate(r); err != nil {
+			return errors.Wrap(err, "unable to update repository")
+		}
+	}
+	featureStore := &feature.FileFeatureStore{Filename: feature.FeaturesFileName}
+	features, err := featureStore.List()
+	if err != nil {
+		return errors.Wrap(err, "unable to load features")
+	}
+	var changed []feature.Feature
+	for _, f := range features {
+		if !moved[f.Repository] {
+			continue
+		}
+		if root, ok := downloader.RelocateRoot(f.Root, config.WorkDir, f.Repository); ok {
+			f.Root = root
+			changed = append(changed, f)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return featureStore.UpdateAll(changed)
+}
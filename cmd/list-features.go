@@ -10,16 +10,20 @@ import (
 
 func ListFeaturesCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "list",
+		Use:   "list [feature names]",
 		Short: "list all registered features",
+		Long: `list all registered features or only the given ones
+Features can be named by their qualified name, like gloo/aws_lambda, or by
+their name if only one repository provides a feature with that name.`,
+		Annotations: readOnly(),
 		Run: func(c *cobra.Command, args []string) {
-			runListFeatures()
+			runListFeatures(args)
 		},
 	}
 	return cmd
 }
 
-func runListFeatures() {
+func runListFeatures(names []string) {
 	store := &feature.FileFeatureStore{Filename: feature.FeaturesFileName}
 	features, err := store.List()
 	if err != nil {
@@ -33,9 +37,22 @@ func runListFeatures() {
 	if len(features) == 0 {
 		fmt.Println("No repositories with features added yet!")
 	}
+	if len(names) != 0 {
+		var selected []feature.Feature
+		for _, name := range names {
+			i, err := feature.Find(features, name)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			selected = append(selected, features[i])
+		}
+		features = selected
+	}
 	for _, f := range features {
 		fmt.Println("Repository:      ", f.Repository)
 		fmt.Println("Name:            ", f.Name)
+		fmt.Println("Qualified Name:  ", f.ID())
 		fmt.Println("Gloo Directory:  ", f.GlooDir)
 		fmt.Println("Envoy Directory: ", f.EnvoyDir)
 		fmt.Println("Enabled:         ", f.Enabled)
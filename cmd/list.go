@@ -9,8 +9,9 @@ import (
 
 func ListReposCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "list-repo",
-		Short: "list all registered Gloo repositories",
+		Use:         "list-repo",
+		Short:       "list all registered Gloo repositories",
+		Annotations: readOnly(),
 		Run: func(c *cobra.Command, args []string) {
 			runListRepos()
 		},
@@ -30,7 +31,17 @@ func runListRepos() {
 	}
 	for _, r := range repos {
 		fmt.Println("Repository: ", r.URL)
+		fmt.Println("Alias:      ", r.ID())
+		if r.Ref != "" && r.Ref != r.Commit {
+			fmt.Println("Ref:        ", r.Ref)
+		}
 		fmt.Println("Commit:     ", r.Commit)
+		if r.IncludedBy != "" {
+			fmt.Println("Included by:", r.IncludedBy)
+		}
+		if r.Subdir != "" || (r.Manifest != "" && r.Manifest != feature.FeaturesFileName) {
+			fmt.Println("Manifest:   ", r.ManifestFile())
+		}
 		fmt.Println("")
 	}
 }
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/addon"
+	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/feature"
+	"github.com/solo-io/thetool/pkg/lock"
+	"github.com/spf13/cobra"
+)
+
+// LockCmd writes the lockfile for the current workspace
+func LockCmd() *cobra.Command {
+	var check bool
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "lock the versions and features used by the build",
+		Long: `Write ` + lock.Filename + ` with the Gloo and Envoy versions, the commit of every
+feature repository, the enabled features and the addon configuration.
+Use 'thetool build --locked' to make sure a build matches the lockfile.`,
+		RunE: func(c *cobra.Command, args []string) error {
+			if check {
+				return checkLock()
+			}
+			return runLock()
+		},
+	}
+	cmd.Flags().BoolVar(&check, "check", false, "only check if the workspace matches the lockfile")
+	return cmd
+}
+
+func runLock() error {
+	l, err := currentLock()
+	if err != nil {
+		return err
+	}
+	if err := l.Save(lock.Filename); err != nil {
+		return errors.Wrapf(err, "unable to save %s", lock.Filename)
+	}
+	fmt.Printf("Locked %d repositories and %d enabled features in %s\n",
+		len(l.Repositories), len(l.Features), lock.Filename)
+	return nil
+}
+
+// checkLock returns an error describing the drift if the workspace doesn't
+// match the lockfile
+func checkLock() error {
+	locked, err := lock.Load(lock.Filename)
+	if err != nil {
+		return errors.Wrapf(err, "unable to load %s", lock.Filename)
+	}
+	current, err := currentLock()
+	if err != nil {
+		return err
+	}
+	if drift := locked.Drift(current); len(drift) != 0 {
+		return fmt.Errorf("workspace does not match %s:\n  %s\nrun 'thetool lock' to update it",
+			lock.Filename, strings.Join(drift, "\n  "))
+	}
+	return nil
+}
+
+func currentLock() (*lock.Lock, error) {
+	conf, err := config.Load(config.ConfigFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load configuration from %s", config.ConfigFile)
+	}
+	repoStore := &feature.FileRepoStore{Filename: feature.ReposFileName}
+	repos, err := repoStore.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load repositories")
+	}
+	features, err := loadFeatures()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load features")
+	}
+	addons, err := addon.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load addons")
+	}
+	return lock.Generate(conf, repos, features, addons)
+}
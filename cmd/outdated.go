@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/downloader"
+	"github.com/solo-io/thetool/pkg/feature"
+	"github.com/spf13/cobra"
+)
+
+type outdatedEntry struct {
+	Name    string     `json:"name"`
+	URL     string     `json:"url"`
+	Current string     `json:"current"`
+	Ref     string     `json:"ref"`
+	Behind  int        `json:"behind"`
+	Latest  string     `json:"latest,omitempty"`
+	Date    *time.Time `json:"date,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+// OutdatedCmd reports how far the repositories and core components are
+// behind their upstream branches or tags
+func OutdatedCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "outdated",
+		Short: "list repositories and components with newer upstream commits",
+		Long: `list repositories and components with newer upstream commits
+Feature repositories are compared with the branch or tag they were added with;
+pinned repositories and the Gloo and Envoy components are compared with the
+default branch of their repository.`,
+		Annotations: readOnly(),
+		RunE: func(c *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("unsupported output format %s; should be table or json", output)
+			}
+			return runOutdated(output)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table or json")
+	return cmd
+}
+
+func runOutdated(output string) error {
+	conf, err := config.Load(config.ConfigFile)
+	if err != nil {
+		return errors.Wrapf(err, "unable to load configuration from %s", config.ConfigFile)
+	}
+	repoStore := &feature.FileRepoStore{Filename: feature.ReposFileName}
+	repos, err := repoStore.List()
+	if err != nil {
+		return errors.Wrap(err, "unable to load repositories")
+	}
+
+	entries := []outdatedEntry{
+		{Name: "gloo", URL: conf.GlooRepo, Current: conf.GlooHash},
+		{Name: "envoy", URL: conf.EnvoyRepo(), Current: conf.EnvoyHash},
+		{Name: "envoy-common", URL: config.EnvoyCommonRepo, Current: conf.EnvoyCommonHash},
+	}
+	for _, r := range repos {
+		if downloader.IsLocal(r.URL) {
+			// local repositories have no upstream to compare with
+			continue
+		}
+		e := outdatedEntry{Name: r.ID(), URL: r.URL, Current: r.Commit}
+		if !downloader.IsCommit(r.Ref) {
+			e.Ref = r.Ref
+		}
+		entries = append(entries, e)
+	}
+	for i := range entries {
+		e := &entries[i]
+		if output == "table" {
+			fmt.Fprintf(os.Stderr, "Checking %s...\n", e.URL)
+		}
+		status, err := downloader.CompareRef(e.URL, e.Current, e.Ref)
+		if e.Ref == "" {
+			e.Ref = "HEAD"
+		}
+		if err != nil {
+			e.Error = err.Error()
+			continue
+		}
+		e.Behind = status.Behind
+		e.Latest = status.Latest
+		e.Date = &status.Date
+	}
+
+	if output == "json" {
+		b, err := json.MarshalIndent(entries, "", " ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCURRENT\tREF\tBEHIND\tLATEST\tDATE")
+	var failed []outdatedEntry
+	for _, e := range entries {
+		if e.Error != "" {
+			fmt.Fprintf(w, "%s\t%s\t%s\terror\t\t\n", e.Name, shortHash(e.Current), e.Ref)
+			failed = append(failed, e)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", e.Name, shortHash(e.Current), e.Ref,
+			e.Behind, shortHash(e.Latest), e.Date.Format("2006-01-02"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, e := range failed {
+		fmt.Printf("\n%s: %s\n", e.Name, e.Error)
+	}
+	return nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
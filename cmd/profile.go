@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/solo-io/thetool/pkg/feature"
+	"github.com/spf13/cobra"
+)
+
+// ProfileCmd manages named sets of enabled features
+func ProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "manage named sets of enabled features",
+	}
+	cmd.AddCommand(profileSaveCmd(), profileUseCmd(), profileListCmd(), profileDeleteCmd())
+	return cmd
+}
+
+func profileSaveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "save [profile name]",
+		Short: "save the currently enabled features as a profile",
+		Args:  cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			runProfileSave(args[0])
+		},
+	}
+}
+
+func profileUseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use [profile name]",
+		Short: "enable exactly the features in a profile",
+		Args:  cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			runProfileUse(args[0])
+		},
+	}
+}
+
+func profileListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:         "list",
+		Short:       "list saved profiles",
+		Annotations: readOnly(),
+		Run: func(c *cobra.Command, args []string) {
+			runProfileList()
+		},
+	}
+}
+
+func profileDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [profile name]",
+		Short: "delete a saved profile",
+		Args:  cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			runProfileDelete(args[0])
+		},
+	}
+}
+
+func profileStore() *feature.FileProfileStore {
+	return &feature.FileProfileStore{
+		Filename: filepath.Join(filepath.Dir(feature.FeaturesFileName), feature.ProfilesFileName),
+	}
+}
+
+func runProfileSave(name string) {
+	features, err := loadFeatures()
+	if err != nil {
+		fmt.Printf("Unable to load feature list: %q\n", err)
+		return
+	}
+	p := feature.NewProfile(name, features)
+	if err := profileStore().Save(p); err != nil {
+		fmt.Printf("Unable to save profile %s: %q\n", name, err)
+		return
+	}
+	fmt.Printf("Saved profile %s with %d features\n", name, len(p.Features))
+}
+
+func runProfileUse(name string) {
+	p, err := profileStore().Get(name)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	store := &feature.FileFeatureStore{Filename: feature.FeaturesFileName}
+	features, err := store.List()
+	if err != nil {
+		fmt.Printf("Unable to load feature list: %q\n", err)
+		return
+	}
+	changed, err := p.Apply(features)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := store.UpdateAll(features); err != nil {
+		fmt.Printf("Unable to update features: %q\n", err)
+		return
+	}
+	if len(changed) == 0 {
+		fmt.Printf("Already using profile %s\n", name)
+		return
+	}
+	fmt.Printf("Using profile %s; changed %s\n", name, strings.Join(changed, ", "))
+}
+
+func runProfileList() {
+	profiles, err := profileStore().List()
+	if err != nil {
+		fmt.Printf("Unable to load profiles: %q\n", err)
+		return
+	}
+	if len(profiles) == 0 {
+		fmt.Println("No profiles saved yet!")
+		return
+	}
+	features, err := loadFeatures()
+	if err != nil {
+		fmt.Printf("Unable to load feature list: %q\n", err)
+		return
+	}
+	for _, p := range profiles {
+		current := " "
+		if p.Matches(features) {
+			current = "*"
+		}
+		fmt.Printf("%s %-20s: %s\n", current, p.Name, strings.Join(p.Features, ", "))
+	}
+}
+
+func runProfileDelete(name string) {
+	if err := profileStore().Remove(name); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Deleted profile %s\n", name)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+// SearchCmd searches the features of the registry index
+func SearchCmd() *cobra.Command {
+	var location string
+	cmd := &cobra.Command{
+		Use:   "search [term]",
+		Short: "search the feature registry",
+		Long: `search the features of the feature registry index
+The term is matched against the name, tags and description of the features
+and the alias, URL and description of their repositories, ignoring case.
+Without a term, all the features are listed. Add a feature found with
+'thetool add --from-registry <feature>'.
+The index is read from --registry, $` + registry.Env + ` or the registry set with
+'thetool configure --registry', in that order; it is a URL or a file path.`,
+		Args:        cobra.MaximumNArgs(1),
+		Annotations: readOnly(),
+		RunE: func(c *cobra.Command, args []string) error {
+			c.SilenceUsage = true
+			term := ""
+			if len(args) != 0 {
+				term = args[0]
+			}
+			return runSearch(location, term)
+		},
+	}
+	cmd.Flags().StringVar(&location, "registry", "", "URL or path of the feature registry index")
+	return cmd
+}
+
+func runSearch(location, term string) error {
+	ix, err := loadRegistry(location)
+	if err != nil {
+		return err
+	}
+	matches := ix.Search(term)
+	if len(matches) == 0 {
+		fmt.Println("No features found in the registry")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FEATURE\tCOMMIT\tTAGS\tDESCRIPTION")
+	for _, m := range matches {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.ID(), m.Repository.Commit,
+			strings.Join(m.Feature.Tags, ","), m.Feature.Description)
+	}
+	return w.Flush()
+}
+
+// loadRegistry loads the registry index from the given location, or else
+// from the one in the environment or the configuration
+func loadRegistry(location string) (*registry.Index, error) {
+	if location == "" {
+		location = os.Getenv(registry.Env)
+	}
+	if location == "" {
+		if conf, err := config.Load(config.ConfigFile); err == nil {
+			location = conf.Registry
+		}
+	}
+	if location == "" {
+		return nil, fmt.Errorf("no feature registry; use --registry, set $%s or run 'thetool configure --registry'", registry.Env)
+	}
+	ix, err := registry.Load(location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load the feature registry %s", location)
+	}
+	return ix, nil
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/auth"
+	"github.com/solo-io/thetool/pkg/downloader"
+	"github.com/solo-io/thetool/pkg/feature"
+	"github.com/spf13/cobra"
+)
+
+// UpdateCmd moves feature repositories to the newest commit on the branch
+// or tag they track, or to a new branch, tag or commit
+func UpdateCmd() *cobra.Command {
+	var repoURL string
+	var ref string
+	var all bool
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "update Gloo feature repositories",
+		Long: `update Gloo feature repositories
+Without a commit, the repository is moved to the newest commit on the branch
+or tag it was added with. Repositories added with a commit hash are pinned
+and only change when a new commit is given.`,
+		RunE: func(c *cobra.Command, args []string) error {
+			if all == (repoURL != "") {
+				return fmt.Errorf("please specify either a repository or --all")
+			}
+			if all && ref != "" {
+				return fmt.Errorf("can't use a commit with --all")
+			}
+			runUpdate(verbose, all, repositoryArg(repoURL), ref)
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&repoURL, "repository", "r", "", "repository URL")
+	flags.StringVarP(&ref, "commit", "c", "", "branch, tag or commit hash to move the repository to")
+	flags.BoolVar(&all, "all", false, "update all repositories")
+	flags.BoolVarP(&verbose, "verbose", "v", false, "verbose logging")
+	return cmd
+}
+
+func runUpdate(verbose, all bool, repoURL, ref string) {
+	repoStore := &feature.FileRepoStore{Filename: feature.ReposFileName}
+	repos, err := repoStore.List()
+	if err != nil {
+		fmt.Printf("Unable to load repository list: %q\n", err)
+		return
+	}
+	found := false
+	for _, r := range repos {
+		if !all && r.URL != repoURL {
+			continue
+		}
+		found = true
+		if err := updateRepo(verbose, r, ref); err != nil {
+			fmt.Printf("Unable to update repository %s: %v\n", r.URL, auth.Redact(err.Error()))
+		}
+	}
+	if !found {
+		fmt.Printf("Unable to find repository %s\n", repoURL)
+	}
+}
+
+func updateRepo(verbose bool, r feature.Repository, ref string) error {
+	if r.IncludedBy != "" {
+		fmt.Printf("Repository %s is included by %s; its commit is in the manifest of that repository\n", r.URL, r.IncludedBy)
+		return nil
+	}
+	if downloader.IsLocal(r.URL) {
+		// pick up the current content and manifest of the directory
+		return runAdd(verbose, r)
+	}
+	if ref == "" {
+		// a repository added with a commit hash only moves to a commit given
+		// explicitly
+		ref = r.Ref
+		if ref == "" || downloader.IsCommit(ref) {
+			fmt.Printf("Repository %s is pinned to commit hash %s\n", r.URL, r.Commit)
+			return nil
+		}
+	}
+	commit, err := downloader.ResolveRef(r.URL, ref)
+	if err != nil {
+		return errors.Wrapf(err, "unable to resolve %s", ref)
+	}
+	if commit == r.Commit && ref == r.Ref {
+		fmt.Printf("Repository %s is up to date with %s\n", r.URL, describeRef(ref, commit))
+		return nil
+	}
+	r.Ref = ref
+	// the archive of another commit has another checksum
+	r.SHA256 = ""
+	return runAdd(verbose, r)
+}
@@ -4,6 +4,9 @@ import (
 	"crypto/sha256"
 	"fmt"
 
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/downloader"
 	"github.com/solo-io/thetool/pkg/feature"
 )
 
@@ -13,15 +16,64 @@ func loadEnabledFeatures() ([]feature.Feature, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := feature.Validate(features); err != nil {
+		return nil, err
+	}
 	var enabled []feature.Feature
 	for _, f := range features {
 		if f.Enabled {
 			enabled = append(enabled, f)
 		}
 	}
+	if err := refreshLocalRevisions(enabled); err != nil {
+		return nil, err
+	}
 	return enabled, nil
 }
 
+// repositoryArg normalizes a repository given on the command line the same
+// way add does, so local repositories can be given by a relative path
+func repositoryArg(repoURL string) string {
+	if downloader.IsLocal(repoURL) {
+		if p, err := downloader.LocalPath(repoURL); err == nil {
+			return p
+		}
+	}
+	return repoURL
+}
+
+// sourceDir is the directory with the content of a repository. Repositories
+// added before fetchers reported it are in the default location.
+func sourceDir(root, repoURL string) string {
+	if root != "" {
+		return root
+	}
+	return downloader.SourceDir(config.WorkDir, repoURL)
+}
+
+// refreshLocalRevisions sets the revision of features from local
+// repositories to the current content hash of the repository, so the image
+// tag changes with the source even if it isn't committed
+func refreshLocalRevisions(features []feature.Feature) error {
+	hashes := make(map[string]string)
+	for i, f := range features {
+		if !downloader.IsLocal(f.Repository) {
+			continue
+		}
+		hash, ok := hashes[f.Repository]
+		if !ok {
+			var err error
+			hash, err = downloader.ContentHash(sourceDir(f.Root, f.Repository))
+			if err != nil {
+				return errors.Wrapf(err, "unable to read local repository %s", f.Repository)
+			}
+			hashes[f.Repository] = hash
+		}
+		features[i].Revision = hash
+	}
+	return nil
+}
+
 // featuresHash generates a hash for particular envoy and gloo build
 // based on the features included
 func featuresHash(features []feature.Feature) string {
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var workspaceLock *workspace.Lock
+
+// LockWorkspace acquires the workspace lock for commands that change the
+// workspace. It fails if another thetool process holds the lock. Holding
+// it, repositories downloaded by older versions are moved to the current
+// layout.
+func LockWorkspace(c *cobra.Command, args []string) error {
+	if c.Name() == "help" || c.Annotations[workspace.ReadOnlyAnnotation] != "" {
+		return nil
+	}
+	l, err := workspace.Acquire(".")
+	if err != nil {
+		c.SilenceUsage = true
+		return err
+	}
+	workspaceLock = l
+	if err := migrateLayout(); err != nil {
+		c.SilenceUsage = true
+		return errors.Wrap(err, "unable to move repositories to the current layout")
+	}
+	return nil
+}
+
+// UnlockWorkspace releases the workspace lock if it is held
+func UnlockWorkspace() {
+	if workspaceLock != nil {
+		workspaceLock.Release()
+		workspaceLock = nil
+	}
+}
+
+func readOnly() map[string]string {
+	return map[string]string{workspace.ReadOnlyAnnotation: "true"}
+}
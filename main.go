@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"os"
 	"time"
 
 	checkpoint "github.com/solo-io/go-checkpoint"
@@ -20,22 +21,39 @@ func main() {
 		Short:   "Build Tool",
 		Long:    "Build the Universe and gloo things together",
 		Version: Version,
+
+		PersistentPreRunE: cmd.LockWorkspace,
 	}
 
 	rootCmd.AddCommand(cmd.InitCmd())
 	rootCmd.AddCommand(cmd.ConfigureCmd())
 	rootCmd.AddCommand(cmd.ListReposCmd())
 	rootCmd.AddCommand(cmd.AddCmd())
+	rootCmd.AddCommand(cmd.UpdateCmd())
+	rootCmd.AddCommand(cmd.OutdatedCmd())
 	rootCmd.AddCommand(cmd.DeleteCmd())
 	rootCmd.AddCommand(cmd.EnableCmd())
 	rootCmd.AddCommand(cmd.DisableCmd())
 	rootCmd.AddCommand(cmd.ListFeaturesCmd())
+	rootCmd.AddCommand(cmd.InfoCmd())
+	rootCmd.AddCommand(cmd.SearchCmd())
+	rootCmd.AddCommand(cmd.ProfileCmd())
+	rootCmd.AddCommand(cmd.LockCmd())
+	rootCmd.AddCommand(cmd.ExportCmd())
+	rootCmd.AddCommand(cmd.FetchCmd())
 	rootCmd.AddCommand(cmd.BuildCmd())
+	rootCmd.AddCommand(cmd.DevCmd())
 	rootCmd.AddCommand(cmd.CleanCmd())
+	rootCmd.AddCommand(cmd.CacheCmd())
 	rootCmd.AddCommand(cmd.DeployCmd())
 	rootCmd.AddCommand(addon.AddonCmd())
 
-	rootCmd.Execute()
+	err := rootCmd.Execute()
+	cmd.UnlockWorkspace()
+	if err != nil {
+		telemetry(start)
+		os.Exit(1)
+	}
 }
 
 func telemetry(t time.Time) {
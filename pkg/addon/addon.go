@@ -1,34 +1,33 @@
+// Package addon manages the add-ons of the workspace saved in addons.json
 package addon
 
 import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"strings"
 	"time"
+
+	"github.com/solo-io/thetool/pkg/workspace"
 )
 
 const (
-	addonFilename = "addons.json"
-	Metrics       = "metrics"
-	OpenTracing   = "opentracing"
+	// Filename is the name of the addon configuration of the workspace
+	Filename    = "addons.json"
+	Metrics     = "metrics"
+	OpenTracing = "opentracing"
 
 	// configuration keys
-	keyStatus = "status"
-	keyEnable = "enable"
-	keyGloo   = "gloo"
+	KeyStatus = "status"
+	KeyEnable = "enable"
+	KeyGloo   = "gloo"
 )
 
-type configurator interface {
-	configure(*Addon)
-}
 type Addon struct {
 	Name          string                 `json:"name"`
 	Configuration map[string]interface{} `json:"configuration"`
 }
 
-var configuratorMap map[string]configurator = make(map[string]configurator)
 var DefaultAddons = []*Addon{
 	newGlooAddon("function-discovery"),
 	newGlooAddon("kube-ingress-controller"),
@@ -38,33 +37,28 @@ var DefaultAddons = []*Addon{
 }
 
 func newGlooAddon(name string) *Addon {
-	configuratorMap[name] = EnableDisable{}
 	return &Addon{
 		Name:          name,
-		Configuration: map[string]interface{}{keyEnable: true, keyGloo: true},
+		Configuration: map[string]interface{}{KeyEnable: true, KeyGloo: true},
 	}
 }
 
 func tracingAddon() *Addon {
-	name := OpenTracing
-	configuratorMap[name] = TracingConfigurator{}
 	return &Addon{
-		Name: name,
+		Name: OpenTracing,
 		Configuration: map[string]interface{}{
 			"jaeger":  "jaegertracing/all-in-one:latest",
-			keyStatus: "disable",
+			KeyStatus: "disable",
 		},
 	}
 }
 
 func metricsAddon() *Addon {
-	name := Metrics
-	configuratorMap[name] = MetricsConfigurator{}
 	return &Addon{
-		Name: name,
+		Name: Metrics,
 		Configuration: map[string]interface{}{
 			"statsd_exporter": "prom/statsd-exporter:latest",
-			keyStatus:         "disable",
+			KeyStatus:         "disable",
 		},
 	}
 }
@@ -75,19 +69,19 @@ func (s *Addon) SafeName() string {
 }
 
 func (s *Addon) IsGlooAddon() bool {
-	return s.Configuration != nil && s.Configuration[keyGloo] == true
+	return s.Configuration != nil && s.Configuration[KeyGloo] == true
 }
 
 func (s *Addon) String() string {
 	b := &bytes.Buffer{}
 	fmt.Fprintf(b, "%-12s: %s\n", "Name", s.Name)
 	if s.Configuration != nil {
-		status, ok := s.Configuration[keyStatus]
+		status, ok := s.Configuration[KeyStatus]
 		if ok {
 			fmt.Fprintf(b, "%-12s: %v\n", "Status", status)
 		}
 
-		enabled, ok := s.Configuration[keyEnable]
+		enabled, ok := s.Configuration[KeyEnable]
 		if ok {
 			fmt.Fprintf(b, "%-12s: %v\n", "Enable", enabled)
 		}
@@ -96,29 +90,35 @@ func (s *Addon) String() string {
 }
 
 func Init() error {
-	return save(addonFilename, DefaultAddons)
+	return save(Filename, DefaultAddons)
+}
+
+// Save replaces the addon configuration of the workspace
+func Save(addons []*Addon) error {
+	return save(Filename, addons)
 }
 
 func List() ([]*Addon, error) {
-	return load(addonFilename)
+	return load(Filename)
 }
 
 // Need a more generic solution
 func InstallPrometheus() bool {
-	addons, err := load(addonFilename)
+	addons, err := load(Filename)
 	if err != nil {
 		return false
 	}
 	for _, a := range addons {
 		if a.Name == Metrics {
-			status := a.Configuration[keyStatus]
+			status := a.Configuration[KeyStatus]
 			return "all" == status
 		}
 	}
 	return false
 }
 
-func addonNames() []string {
+// Names lists the names of the addons of the workspace
+func Names() []string {
 	addons, err := List()
 	if err != nil {
 		return []string{}
@@ -130,28 +130,32 @@ func addonNames() []string {
 	return names
 }
 
-// save and load; move to it to pkg/addon?
 type addonFile struct {
-	Date        time.Time `json:"date"`
-	GeneratedBy string    `json:"generatedBy"`
-	Addons      []*Addon  `json:"addons"`
+	SchemaVersion int       `json:"schemaVersion"`
+	Date          time.Time `json:"date"`
+	GeneratedBy   string    `json:"generatedBy"`
+	Addons        []*Addon  `json:"addons"`
 }
 
+// addonSchema versions the addons file
+var addonSchema = workspace.Schema{Version: 1}
+
 func save(filename string, addons []*Addon) error {
 	b, err := json.MarshalIndent(addonFile{
-		Date:        time.Now(),
-		GeneratedBy: "thetool",
-		Addons:      addons,
+		SchemaVersion: addonSchema.Version,
+		Date:          time.Now(),
+		GeneratedBy:   "thetool",
+		Addons:        addons,
 	}, "", " ")
 	if err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(filename, b, 0644)
+	return workspace.WriteFile(filename, b, 0644)
 }
 
 func load(filename string) ([]*Addon, error) {
-	b, err := ioutil.ReadFile(filename)
+	b, err := addonSchema.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,209 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/addon"
+	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/feature"
+	"github.com/solo-io/thetool/pkg/workspace"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// FormatJSON writes the bundle as JSON
+	FormatJSON = "json"
+	// FormatYAML writes the bundle as YAML
+	FormatYAML = "yaml"
+)
+
+// schema versions the bundle file. The configuration in the bundle is
+// versioned on its own.
+var schema = workspace.Schema{Version: 1}
+
+// Feature records a feature of a repository and if it is enabled
+type Feature struct {
+	Repository string `json:"repository"`
+	Name       string `json:"name"`
+	Enabled    bool   `json:"enabled"`
+}
+
+// Bundle is a complete workspace in a single file: the configuration, the
+// repositories at their commits, the features with their status and the
+// addon configuration
+type Bundle struct {
+	SchemaVersion int                  `json:"schemaVersion"`
+	Date          time.Time            `json:"date"`
+	GeneratedBy   string               `json:"generatedBy"`
+	Config        *config.Config       `json:"config"`
+	Repositories  []feature.Repository `json:"repositories"`
+	Features      []Feature            `json:"features"`
+	Addons        []*addon.Addon       `json:"addons"`
+}
+
+// New creates the bundle for the given workspace content
+func New(conf *config.Config, repos []feature.Repository, features []feature.Feature, addons []*addon.Addon) *Bundle {
+	c := *conf
+	c.SchemaVersion = config.Schema.Version
+	b := &Bundle{
+		SchemaVersion: schema.Version,
+		Date:          time.Now(),
+		GeneratedBy:   "thetool",
+		Config:        &c,
+		Repositories:  repos,
+		Features:      make([]Feature, len(features)),
+		Addons:        addons,
+	}
+	for i, f := range features {
+		b.Features[i] = Feature{Repository: f.Repository, Name: f.Name, Enabled: f.Enabled}
+	}
+	return b
+}
+
+// Check makes sure the features loaded from the manifests of the
+// repositories are the ones recorded in the bundle
+func (b *Bundle) Check(features []feature.Feature) error {
+	var problems []string
+	for _, r := range b.Features {
+		if b.find(features, r) == -1 {
+			problems = append(problems, fmt.Sprintf("feature %s of repository %s is no longer in the manifest", r.Name, r.Repository))
+		}
+	}
+	for _, f := range features {
+		found := false
+		for _, r := range b.Features {
+			found = found || (r.Name == f.Name && r.Repository == f.Repository)
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("feature %s of repository %s is not in the bundle", f.Name, f.Repository))
+		}
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("manifests don't match the bundle:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Apply restores the status of the features recorded in the bundle
+func (b *Bundle) Apply(features []feature.Feature) {
+	for _, r := range b.Features {
+		if i := b.find(features, r); i != -1 {
+			features[i].Enabled = r.Enabled
+		}
+	}
+}
+
+func (b *Bundle) find(features []feature.Feature, r Feature) int {
+	for i, f := range features {
+		if f.Name == r.Name && f.Repository == r.Repository {
+			return i
+		}
+	}
+	return -1
+}
+
+// Format guesses the format of the bundle file from its extension
+func Format(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	default:
+		return FormatJSON
+	}
+}
+
+// Marshal encodes the bundle in the given format. YAML uses the same field
+// names as JSON.
+func (b *Bundle) Marshal(format string) ([]byte, error) {
+	data, err := json.MarshalIndent(b, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatJSON:
+		return append(data, '\n'), nil
+	case FormatYAML:
+		var v yaml.MapSlice
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(v)
+	default:
+		return nil, fmt.Errorf("unsupported bundle format %s", format)
+	}
+}
+
+// Save writes the bundle to a file in the format matching its extension
+func (b *Bundle) Save(filename string) error {
+	data, err := b.Marshal(Format(filename))
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// Load reads a bundle from a JSON or YAML file
+func Load(filename string) (*Bundle, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if Format(filename) == FormatYAML {
+		err = yaml.Unmarshal(data, &v)
+	} else {
+		err = json.Unmarshal(data, &v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := jsonValue(v).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not a workspace file", filename)
+	}
+	if _, err := schema.Migrate(doc); err != nil {
+		return nil, errors.Wrap(err, filename)
+	}
+	if conf, ok := doc["config"].(map[string]interface{}); ok {
+		if _, err := config.Schema.Migrate(conf); err != nil {
+			return nil, errors.Wrapf(err, "%s: configuration", filename)
+		}
+	}
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, err
+	}
+	if b.Config == nil {
+		return nil, fmt.Errorf("%s does not contain a thetool configuration", filename)
+	}
+	return b, nil
+}
+
+// jsonValue converts the maps decoded from YAML to maps JSON can encode
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[fmt.Sprint(k)] = jsonValue(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range t {
+			t[i] = jsonValue(e)
+		}
+		return t
+	default:
+		return v
+	}
+}
@@ -0,0 +1,80 @@
+package bundle
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/solo-io/thetool/pkg/addon"
+	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/feature"
+)
+
+func testBundle() (*Bundle, []feature.Feature) {
+	conf := &config.Config{GlooRepo: config.GlooRepo, GlooHash: "g1", EnvoyHash: "e1"}
+	repos := []feature.Repository{
+		{URL: "https://github.com/solo-io/gloo.git", Ref: "master", Commit: "g1", Manifest: "pkg/plugins/features.json"},
+	}
+	features := []feature.Feature{
+		{Name: "aws_lambda", Repository: repos[0].URL, Revision: "g1", Enabled: true},
+		{Name: "nats", Repository: repos[0].URL, Revision: "g1", Enabled: false},
+	}
+	addons := []*addon.Addon{
+		{Name: "metrics", Configuration: map[string]interface{}{"status": "all", "port": 9102.0}},
+	}
+	return New(conf, repos, features, addons), features
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thetool-bundle")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, _ := testBundle()
+	for _, name := range []string{"workspace.json", "workspace.yaml"} {
+		filename := filepath.Join(dir, name)
+		if err := b.Save(filename); err != nil {
+			t.Fatalf("unable to save %s: %v", name, err)
+		}
+		loaded, err := Load(filename)
+		if err != nil {
+			t.Fatalf("unable to load %s: %v", name, err)
+		}
+		if !loaded.Date.Equal(b.Date) {
+			t.Errorf("%s: expected date %v got %v", name, b.Date, loaded.Date)
+		}
+		loaded.Date = b.Date
+		if !reflect.DeepEqual(loaded, b) {
+			t.Errorf("%s: expected %+v got %+v", name, b, loaded)
+		}
+	}
+}
+
+func TestCheckAndApply(t *testing.T) {
+	b, features := testBundle()
+	features[0].Enabled = false
+	features[1].Enabled = true
+	if err := b.Check(features); err != nil {
+		t.Fatal("expected features to match", err)
+	}
+	b.Apply(features)
+	if !features[0].Enabled || features[1].Enabled {
+		t.Errorf("expected status to be restored got %+v", features)
+	}
+
+	features[1].Name = "nats_streaming"
+	err := b.Check(features)
+	if err == nil {
+		t.Fatal("expected renamed feature to be reported")
+	}
+	for _, expected := range []string{"feature nats of repository", "feature nats_streaming of repository"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %q", expected, err)
+		}
+	}
+}
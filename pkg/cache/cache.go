@@ -0,0 +1,210 @@
+// Package cache manages the sources shared by all workspaces of a user, so
+// repositories are cloned or downloaded once and copied from there
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/filelock"
+)
+
+const (
+	// DirEnv overrides the directory of the cache
+	DirEnv = "THETOOL_CACHE_DIR"
+	// KindGit is a bare mirror of a git repository
+	KindGit = "git"
+	// KindArchive is a downloaded archive of one revision of a repository
+	KindArchive = "archive"
+
+	// incompleteAge is how long a directory without an entry record is
+	// kept, as it may be a download or clone in progress
+	incompleteAge = 24 * time.Hour
+
+	// entryFile holds what an entry is; its modification time is when the
+	// entry was last used
+	entryFile = "thetool-entry.json"
+)
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Entry is a cached repository
+type Entry struct {
+	Kind string `json:"kind"`
+	URL  string `json:"url"`
+	// Revision is set for archives, which hold a single revision
+	Revision string `json:"revision,omitempty"`
+
+	Dir      string    `json:"-"`
+	Size     int64     `json:"-"`
+	LastUsed time.Time `json:"-"`
+}
+
+// Dir is the directory of the cache: $THETOOL_CACHE_DIR, or thetool/sources
+// in the cache directory of the user
+func Dir() (string, error) {
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to find the cache directory; set "+DirEnv)
+	}
+	return filepath.Join(dir, "thetool", "sources"), nil
+}
+
+// Path is the directory of the entry for the repository. Mirrors of git
+// repositories are keyed by their URL and archives by URL and revision.
+func Path(kind, url, revision string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	url = withoutUserInfo(url)
+	key := url
+	if kind == KindArchive {
+		key = url + "@" + revision
+	}
+	name := url
+	if i := strings.Index(name, "://"); i != -1 {
+		name = name[i+3:]
+	}
+	name = strings.Trim(unsafeChars.ReplaceAllString(name, "-"), "-")
+	if len(name) > 60 {
+		name = name[len(name)-60:]
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, kind, fmt.Sprintf("%s-%x", name, sum[:6])), nil
+}
+
+// withoutUserInfo removes the credentials from the URL so they aren't kept
+// in the cache
+func withoutUserInfo(rawURL string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	u.User = nil
+	return u.String()
+}
+
+// Touch records the entry in its directory and marks it as used now
+func Touch(e Entry) error {
+	e.URL = withoutUserInfo(e.URL)
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(e.Dir, entryFile), b, 0644)
+}
+
+// List returns the cached repositories, the least recently used first
+func List() ([]Entry, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, kind := range []string{KindGit, KindArchive} {
+		files, err := ioutil.ReadDir(filepath.Join(dir, kind))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, fi := range files {
+			e, err := load(filepath.Join(dir, kind, fi.Name()))
+			if err != nil {
+				// left behind by an interrupted download
+				e = Entry{Kind: kind, Dir: filepath.Join(dir, kind, fi.Name()), LastUsed: fi.ModTime()}
+			}
+			e.Size = size(e.Dir)
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LastUsed.Before(entries[j].LastUsed) })
+	return entries, nil
+}
+
+func load(dir string) (Entry, error) {
+	filename := filepath.Join(dir, entryFile)
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Entry{}, err
+	}
+	e := Entry{}
+	if err := json.Unmarshal(b, &e); err != nil {
+		return Entry{}, err
+	}
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return Entry{}, err
+	}
+	e.Dir, e.LastUsed = dir, fi.ModTime()
+	return e, nil
+}
+
+func size(dir string) int64 {
+	var total int64
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// Prune removes the entries that were not used for the given time and
+// returns them. Entries being fetched are skipped, as are directories
+// without an entry record that may still be downloaded or cloned.
+func Prune(unused time.Duration) ([]Entry, error) {
+	entries, err := List()
+	if err != nil {
+		return nil, err
+	}
+	var removed []Entry
+	for _, e := range entries {
+		if time.Since(e.LastUsed) < unused {
+			continue
+		}
+		if e.URL == "" && time.Since(e.LastUsed) < incompleteAge {
+			continue
+		}
+		ok, err := remove(e.Dir)
+		if err != nil {
+			return removed, err
+		}
+		if ok {
+			removed = append(removed, e)
+		}
+	}
+	return removed, nil
+}
+
+// remove removes the entry in dir unless it is locked
+func remove(dir string) (bool, error) {
+	unlock, err := lock(dir, false)
+	if err == filelock.ErrLocked {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	if err := os.RemoveAll(dir); err != nil {
+		return false, errors.Wrapf(err, "unable to remove %s", dir)
+	}
+	return true, nil
+}
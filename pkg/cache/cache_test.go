@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPath(t *testing.T) {
+	os.Setenv(DirEnv, "/cache")
+	defer os.Unsetenv(DirEnv)
+
+	a, _ := Path(KindGit, "git@github.com:solo-io/gloo.git", "")
+	if filepath.Dir(a) != filepath.Join("/cache", KindGit) || !strings.HasPrefix(filepath.Base(a), "git-github.com-solo-io-gloo.git-") {
+		t.Errorf("unexpected path %s", a)
+	}
+	b, _ := Path(KindGit, "https://github.com/solo-io/gloo.git", "")
+	if a == b {
+		t.Error("expected different URLs to have different entries")
+	}
+	v1, _ := Path(KindArchive, "https://example.com/plugins.zip", "1")
+	v2, _ := Path(KindArchive, "https://example.com/plugins.zip", "2")
+	if v1 == v2 {
+		t.Error("expected every revision of an archive to have its own entry")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thetool-cache")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv(DirEnv, dir)
+	defer os.Unsetenv(DirEnv)
+
+	for _, url := range []string{"https://example.com/old.git", "https://example.com/new.git"} {
+		p, _ := Path(KindGit, url, "")
+		os.MkdirAll(p, 0755)
+		ioutil.WriteFile(filepath.Join(p, "HEAD"), []byte("ref: refs/heads/master\n"), 0644)
+		if err := Touch(Entry{Kind: KindGit, URL: url, Dir: p}); err != nil {
+			t.Fatal("unable to record entry", err)
+		}
+	}
+	old, _ := Path(KindGit, "https://example.com/old.git", "")
+	lastMonth := time.Now().Add(-30 * 24 * time.Hour)
+	os.Chtimes(filepath.Join(old, entryFile), lastMonth, lastMonth)
+
+	entries, err := List()
+	if err != nil {
+		t.Fatal("unable to list cache", err)
+	}
+	if len(entries) != 2 || entries[0].URL != "https://example.com/old.git" || entries[0].Size == 0 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	removed, err := Prune(7 * 24 * time.Hour)
+	if err != nil {
+		t.Fatal("unable to prune cache", err)
+	}
+	if len(removed) != 1 || removed[0].URL != "https://example.com/old.git" {
+		t.Errorf("expected the old entry to be removed, got %+v", removed)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("expected the old entry to be deleted")
+	}
+}
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thetool-cache")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv(DirEnv, dir)
+	defer os.Unsetenv(DirEnv)
+
+	p, _ := Path(KindGit, "https://example.com/plugins.git", "")
+	unlock, err := Lock(p)
+	if err != nil {
+		t.Fatal("unable to lock entry", err)
+	}
+	locked := make(chan struct{})
+	go func() {
+		unlockAgain, err := Lock(p)
+		if err == nil {
+			unlockAgain()
+		}
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("expected the entry to stay locked until it is unlocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the entry to be locked once it was unlocked")
+	}
+	if entries, err := List(); err != nil || len(entries) != 0 {
+		t.Errorf("expected the lock not to be listed as an entry, got %+v %v", entries, err)
+	}
+}
+
+func TestPruneSkipsEntriesInUse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thetool-cache")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv(DirEnv, dir)
+	defer os.Unsetenv(DirEnv)
+
+	var paths []string
+	for _, url := range []string{"https://example.com/fetching.git", "https://example.com/unused.git"} {
+		p, _ := Path(KindGit, url, "")
+		os.MkdirAll(p, 0755)
+		if err := Touch(Entry{Kind: KindGit, URL: url, Dir: p}); err != nil {
+			t.Fatal("unable to record entry", err)
+		}
+		paths = append(paths, p)
+	}
+	// a download that hasn't recorded its entry yet
+	downloading, _ := Path(KindArchive, "https://example.com/plugins.zip", "1.0")
+	os.MkdirAll(downloading, 0755)
+
+	unlock, err := Lock(paths[0])
+	if err != nil {
+		t.Fatal("unable to lock entry", err)
+	}
+	defer unlock()
+	removed, err := Prune(0)
+	if err != nil {
+		t.Fatal("unable to prune cache", err)
+	}
+	if len(removed) != 1 || removed[0].Dir != paths[1] {
+		t.Errorf("expected only the unused entry to be removed, got %+v", removed)
+	}
+	for _, p := range []string{paths[0], downloading} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("expected %s to be kept: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/filelock"
+)
+
+// locksDir holds the lock files of the entries, next to the kinds of
+// entries so they aren't listed as entries
+const locksDir = "locks"
+
+// Lock waits until no other fetch, in this or another process, uses the
+// entry in dir and locks it. The returned function unlocks it.
+func Lock(dir string) (func(), error) {
+	return lock(dir, true)
+}
+
+// lock locks the entry in dir, failing with filelock.ErrLocked if it is
+// locked and wait isn't set
+func lock(dir string, wait bool) (func(), error) {
+	kindDir := filepath.Dir(dir)
+	locks := filepath.Join(filepath.Dir(kindDir), locksDir)
+	if err := os.MkdirAll(locks, 0755); err != nil {
+		return nil, errors.Wrap(err, "unable to create the cache directory")
+	}
+	f, err := filelock.Lock(filepath.Join(locks, filepath.Base(kindDir)+"-"+filepath.Base(dir)+".lock"), wait)
+	if err == filelock.ErrLocked {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to lock %s", dir)
+	}
+	return func() { filelock.Unlock(f) }, nil
+}
@@ -1,9 +1,14 @@
 package common
 
 import (
+	"fmt"
+	"os"
 	"os/user"
+	"sort"
 
 	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/auth"
+	"github.com/solo-io/thetool/pkg/downloader"
 )
 
 func GetUidArgs() ([]string, error) {
@@ -25,6 +30,30 @@ func GetSshKeyArgs(sshkey string) []string {
 	return args
 }
 
+// GetCredentialArgs mounts the SSH key, the credentials for the repositories
+// and the ssh-agent of the host into a build container. The credentials are
+// in a temporary file, never in the build script or the command line; the
+// returned function removes it once the container is done.
+func GetCredentialArgs(sshKeyFile string, repoURLs []string) ([]string, func(), error) {
+	var args []string
+	if sshKeyFile != "" {
+		args = append(args, GetSshKeyArgs(sshKeyFile)...)
+	}
+	cleanup := func() {}
+	store, err := auth.StoreFile(repoURLs)
+	if err != nil {
+		return nil, cleanup, errors.Wrap(err, "unable to write the credentials for the build")
+	}
+	if store != "" {
+		args = append(args, "-v", store+":/etc/user-data/git-credentials:ro")
+		cleanup = func() { os.Remove(store) }
+	}
+	if sock := auth.AgentSocket(); sock != "" {
+		args = append(args, "-v", sock+":/etc/user-data/ssh-agent.sock")
+	}
+	return args, cleanup, nil
+}
+
 func CreateUserTemplate(homedir string) string {
 	return `
 if [ -n "$THETOOL_UID" ]; then
@@ -43,3 +72,46 @@ const PrepareKeyTemplate = `
 	  chown thetool /etc/github/id_rsa
 	fi
 	`
+
+// CredentialsTemplate makes git use the credentials and the ssh-agent
+// mounted by GetCredentialArgs. It is part of the script run as the build
+// user, whose git configuration it changes.
+const CredentialsTemplate = `
+if [ -f "/etc/user-data/git-credentials" ]; then
+  git config --global credential.helper "store --file=/etc/user-data/git-credentials"
+fi
+if [ -S "/etc/user-data/ssh-agent.sock" ]; then
+  export SSH_AUTH_SOCK=/etc/user-data/ssh-agent.sock
+  git config --global core.sshCommand "ssh -o 'StrictHostKeyChecking no'"
+fi
+`
+
+// SSHRewrites are the commands making git fetch from the hosts of the
+// repositories over SSH instead of HTTPS, for builds given an SSH key. Go
+// tools fetch repositories by their import path, which is an HTTPS URL.
+// Repositories given by an SSH URL keep their user and port.
+func SSHRewrites(repoURLs []string) string {
+	bases := make(map[string]string)
+	for _, r := range repoURLs {
+		if downloader.IsLocal(r) {
+			continue
+		}
+		u, err := downloader.ParseGitURL(r)
+		if err != nil || u.Scheme == "file" {
+			continue
+		}
+		if _, ok := bases[u.Host]; !ok || u.Scheme == "ssh" {
+			bases[u.Host] = u.SSHBase()
+		}
+	}
+	hosts := make([]string, 0, len(bases))
+	for h := range bases {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	var s string
+	for _, h := range hosts {
+		s += fmt.Sprintf("\tgit config --global url.\"%s\".insteadOf \"https://%s/\"\n", bases[h], h)
+	}
+	return s
+}
@@ -3,10 +3,11 @@ package component
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/pkg/errors"
-	"github.com/solo-io/thetool/cmd/addon"
+	"github.com/solo-io/thetool/pkg/addon"
 	"github.com/solo-io/thetool/pkg/downloader"
 	"github.com/solo-io/thetool/pkg/util"
 
@@ -29,9 +30,20 @@ type BuilderConfig struct {
 	Config       *config.Config
 }
 
+// Builder builds and publishes a component
 type Builder struct {
 	Name    string
-	Builder func(BuilderConfig)
+	Builder func(BuilderConfig) error
+}
+
+// Find returns the builder of the named component
+func Find(name string) (Builder, bool) {
+	for _, b := range Builders {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return Builder{}, false
 }
 
 const (
@@ -54,33 +66,26 @@ func Components() []string {
 func init() {
 	Builders = append(Builders, Builder{
 		Name: "envoy",
-		Builder: func(b BuilderConfig) {
+		Builder: func(b BuilderConfig) error {
 			if err := envoy.Build(b.Enabled, b.Verbose, b.DryRun, b.UseCache, b.SSHKeyFile,
 				b.Config.EnvoyHash, b.Config.EnvoyCommonHash, b.Config.EnvoyRepoUser, config.WorkDir,
 				b.Config.EnvoyBuilderHash); err != nil {
-				fmt.Println(err)
-				return
-			}
-			if err := envoy.Publish(b.Verbose, b.DryRun, b.PublishImage, b.ImageTag, b.DockerUser); err != nil {
-				fmt.Println(err)
-				return
+				return err
 			}
+			return envoy.Publish(b.Verbose, b.DryRun, b.PublishImage, b.ImageTag, b.DockerUser)
 		},
 	})
 
 	Builders = append(Builders, Builder{
 		Name: "gloo",
-		Builder: func(b BuilderConfig) {
+		Builder: func(b BuilderConfig) error {
 			if err := gloo.Build(b.Enabled, b.Verbose, b.DryRun, b.UseCache, b.SSHKeyFile,
 				b.Config.GlooRepo, b.Config.GlooHash, config.WorkDir); err != nil {
-				fmt.Println(err)
-				return
+				return err
 			}
 
-			if err := gloo.Publish(b.Verbose, b.DryRun, b.PublishImage,
-				config.WorkDir, b.ImageTag, b.DockerUser); err != nil {
-				fmt.Println(err)
-			}
+			return gloo.Publish(b.Verbose, b.DryRun, b.PublishImage,
+				b.Config.GlooRepo, config.WorkDir, b.ImageTag, b.DockerUser)
 		},
 	})
 
@@ -94,18 +99,14 @@ func init() {
 		if isGlooAddon(srv) {
 			builder := Builder{
 				Name: srv.Name,
-				Builder: func(b BuilderConfig) {
+				Builder: func(b BuilderConfig) error {
 					if err := buildRepo(b.Verbose, b.DryRun, b.UseCache, b.SSHKeyFile,
 						srv.Name, b.Config.GlooRepo, b.Config.GlooHash, config.WorkDir); err != nil {
-						fmt.Println(err)
-						return
+						return err
 					}
 
-					if err := publishRepo(b.Verbose, b.DryRun, b.PublishImage, srv.Name,
-						b.Config.GlooRepo, config.WorkDir, b.ImageTag, b.DockerUser); err != nil {
-						fmt.Println(err)
-						return
-					}
+					return publishRepo(b.Verbose, b.DryRun, b.PublishImage, srv.Name,
+						b.Config.GlooRepo, config.WorkDir, b.ImageTag, b.DockerUser)
 				},
 			}
 			Builders = append(Builders, builder)
@@ -148,9 +149,12 @@ func buildRepo(verbose, dryRun, useCache bool, sshKeyFile, name, repo, hash, wor
 	containerName := "thetool-" + name
 	args := []string{"run", "-i", "--rm", "--name", containerName, "-v", pwd + ":/code"}
 
-	if sshKeyFile != "" {
-		args = append(args, common.GetSshKeyArgs(sshKeyFile)...)
+	cargs, cleanup, err := common.GetCredentialArgs(sshKeyFile, []string{repo})
+	if err != nil {
+		return err
 	}
+	defer cleanup()
+	args = append(args, cargs...)
 
 	uargs, err := common.GetUidArgs()
 	if err != nil {
@@ -206,11 +210,17 @@ func generateBuildScript(filename, workDir, name, repoURL string) error {
 		return errors.Wrap(err, "unable to create file: "+filename)
 	}
 	defer f.Close()
+	importPath, err := repoImportPath(repoURL)
+	if err != nil {
+		return err
+	}
 	err = buildSriptTemplate.Execute(f, map[string]string{
-		"repoParent": repoParent(repoURL),
-		"repoDir":    downloader.RepoDir(repoURL),
-		"name":       name,
-		"workDir":    workDir,
+		"repoParent":  path.Dir(importPath),
+		"repoDir":     filepath.ToSlash(downloader.RepoDir(repoURL)),
+		"repoName":    path.Base(importPath),
+		"sshRewrites": common.SSHRewrites([]string{repoURL}),
+		"name":        name,
+		"workDir":     filepath.ToSlash(workDir),
 	})
 	if err != nil {
 		return errors.Wrap(err, "unable to write file: "+filename)
@@ -218,7 +228,15 @@ func generateBuildScript(filename, workDir, name, repoURL string) error {
 	return nil
 }
 
-func repoParent(repoURL string) string {
-	// TODO (ashish)
-	return "github.com/solo-io"
+// repoImportPath is the Go import path the repository is linked at in the
+// GOPATH of the build container
+func repoImportPath(repoURL string) (string, error) {
+	if downloader.IsLocal(repoURL) {
+		return downloader.LocalImportPath(repoURL), nil
+	}
+	u, err := downloader.ParseGitURL(repoURL)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to find the import path of "+repoURL)
+	}
+	return u.ImportPath(), nil
 }
@@ -22,16 +22,15 @@ fi
 cat << EOF > build_user.sh
 #!/bin/bash
 set -ex
-PATH="$PATH"
+` + common.CredentialsTemplate + `PATH="$PATH"
 cd $GOPATH
 if [ -n "$GIT_SSH_COMMAND" ]; then
 	GIT_SSH_COMMAND="$GIT_SSH_COMMAND"
-	git config --global url.\"git@github.com:\".insteadOf \"https://github.com\"
-fi
+{{ .sshRewrites }}fi
 mkdir -p -v src/{{ .repoParent }}
 cd src/{{ .repoParent }}
-ln -s /code/{{ .workDir }}/{{ .repoDir }} .
-cd {{ .repoDir }}
+ln -s /code/{{ .workDir }}/{{ .repoDir }} {{ .repoName }}
+cd {{ .repoName }}
 pwd
 go get -u github.com/golang/dep/cmd/dep
 dep ensure -vendor-only
@@ -1,10 +1,11 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
-	"io/ioutil"
-	"os"
+
+	"github.com/solo-io/thetool/pkg/workspace"
 )
 
 const (
@@ -20,6 +21,8 @@ const (
 
 	// EnvoyCommonHash
 	EnvoyCommonHash = "771b89c20a7a6f8edf3ebe3df2358f0e07e7edcd"
+	// EnvoyCommonRepo is the repository URL for Solo.io Envoy common
+	EnvoyCommonRepo = "https://github.com/solo-io/envoy-common.git"
 
 	// GlooHash is the commit hash of the version of Gloo used
 	GlooHash = "2246f0e8e3e8739e0f2659ff114eb83e35ddd19d"
@@ -34,23 +37,48 @@ const (
 
 // Config contains the configuration used by thetool
 type Config struct {
+	SchemaVersion    int    `json:"schemaVersion"`
 	EnvoyRepoUser    string `json:"envoyRepoUser"`
 	EnvoyHash        string `json:"envoyHash"`
-	EnvoyCommonHash  string `jsno:"envoyCommonHash"`
+	EnvoyCommonHash  string `json:"envoyCommonHash"`
 	EnvoyBuilderHash string `json:"envoyBuilderHash"`
 	GlooHash         string `json:"glooHash"`
 	GlooRepo         string `json:"glooRepo"`
 	DockerUser       string `json:"dockerUser,omitempty"`
+	// Registry is the location of the feature registry index
+	Registry string `json:"registry,omitempty"`
+}
+
+// Schema versions the configuration file. Version 1 fixes the name of the
+// Envoy common hash, which used to be saved as EnvoyCommonHash.
+var Schema = workspace.Schema{
+	Version: 1,
+	Migrations: map[int]workspace.Migration{
+		0: func(doc map[string]interface{}) error {
+			if v, ok := doc["EnvoyCommonHash"]; ok {
+				if _, ok := doc["envoyCommonHash"]; !ok {
+					doc["envoyCommonHash"] = v
+				}
+				delete(doc, "EnvoyCommonHash")
+			}
+			return nil
+		},
+	},
+}
+
+// EnvoyRepo is the repository URL for Envoy
+func (c *Config) EnvoyRepo() string {
+	return "https://github.com/" + c.EnvoyRepoUser + "/envoy.git"
 }
 
 // Save the current configuration used by thetool to a file
 func (c *Config) Save(filename string) error {
-	f, err := os.Create(filename)
-	if err != nil {
+	c.SchemaVersion = Schema.Version
+	buf := &bytes.Buffer{}
+	if err := saveToWriter(c, buf); err != nil {
 		return err
 	}
-	defer f.Close()
-	return saveToWriter(c, f)
+	return workspace.WriteFile(filename, buf.Bytes(), 0644)
 }
 
 func saveToWriter(c *Config, w io.Writer) error {
@@ -64,16 +92,7 @@ func saveToWriter(c *Config, w io.Writer) error {
 
 // Load the configuration for thetool from a file
 func Load(filename string) (*Config, error) {
-	f, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	return loadFromReader(f)
-}
-
-func loadFromReader(r io.Reader) (*Config, error) {
-	buf, err := ioutil.ReadAll(r)
+	buf, err := Schema.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMigratesEnvoyCommonHash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thetool-config")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, ConfigFile)
+	old := `{"envoyHash": "e1", "EnvoyCommonHash": "c1", "glooHash": "g1"}`
+	if err := ioutil.WriteFile(filename, []byte(old), 0644); err != nil {
+		t.Fatal("unable to write configuration", err)
+	}
+
+	c, err := Load(filename)
+	if err != nil {
+		t.Fatal("unable to load configuration", err)
+	}
+	if c.EnvoyCommonHash != "c1" || c.EnvoyHash != "e1" || c.SchemaVersion != Schema.Version {
+		t.Errorf("unexpected configuration %+v", c)
+	}
+	if err := c.Save(filename); err != nil {
+		t.Fatal("unable to save configuration", err)
+	}
+	c, err = Load(filename)
+	if err != nil || c.EnvoyCommonHash != "c1" {
+		t.Errorf("expected Envoy common hash to be saved got %+v %v", c, err)
+	}
+}
@@ -0,0 +1,283 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/auth"
+	"github.com/solo-io/thetool/pkg/cache"
+)
+
+// archiveFetcher downloads an archive over HTTP and expands it into the
+// directory of the repository
+type archiveFetcher struct {
+	// archiveURL maps the repository and revision to the URL of the archive;
+	// the repository URL is the archive if it is not set
+	archiveURL func(repoURL, revision string) string
+	// keepTopDir keeps the single top-level directory of an archive
+	// instead of expanding its content into the repository directory
+	keepTopDir bool
+}
+
+// NewArchiveFetcher creates a fetcher for repositories downloaded as an
+// archive over HTTP. archiveURL maps a repository and revision to the URL of
+// the archive; the repository URL is used if it is nil. The single top-level
+// directory most archives have is stripped unless keepTopDir is set.
+func NewArchiveFetcher(archiveURL func(repoURL, revision string) string, keepTopDir bool) Fetcher {
+	return archiveFetcher{archiveURL: archiveURL, keepTopDir: keepTopDir}
+}
+
+func (a archiveFetcher) Fetch(repoURL, revision, workDir string, opts Options) (*Result, error) {
+	source := repoURL
+	if a.archiveURL != nil {
+		source = a.archiveURL(repoURL, revision)
+	}
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "unable to create %s", workDir)
+	}
+	archive, err := download(source, revision, workDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.unlock()
+	if archive.temporary {
+		defer os.Remove(archive.name)
+	}
+	format, err := detectFormat(archive.name, source, archive.contentType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to expand the archive %s", auth.Redact(source))
+	}
+
+	// expand next to the previous download, which is only replaced by a
+	// complete and verified one
+	staging, err := ioutil.TempDir(workDir, ".expand-")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create temporary directory to expand archive")
+	}
+	defer os.RemoveAll(staging)
+	if err := expand(archive.name, format, staging); err != nil {
+		return nil, errors.Wrapf(err, "unable to expand the archive %s", auth.Redact(source))
+	}
+	content := staging
+	top, err := topDir(staging)
+	if err != nil {
+		return nil, err
+	}
+	if top != "" && !a.keepTopDir {
+		content = filepath.Join(staging, top)
+	}
+
+	dir := filepath.Join(workDir, RepoDir(repoURL))
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, errors.Wrapf(err, "unable to remove previous download of %s", repoURL)
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(content, dir); err != nil {
+		return nil, errors.Wrapf(err, "unable to move expanded archive to %s", dir)
+	}
+	root := dir
+	if top != "" && a.keepTopDir {
+		root = filepath.Join(dir, top)
+	}
+	return &Result{Revision: revision, Root: root, SHA256: archive.sha256}, nil
+}
+
+// downloaded is an archive on disk. It is temporary if it couldn't be kept
+// in the shared cache. Its cache entry stays locked until it is unlocked.
+type downloaded struct {
+	name        string
+	contentType string
+	sha256      string
+	temporary   bool
+	unlock      func()
+}
+
+// download returns the archive downloaded from the source. Archives
+// are kept in the shared cache, but only reused when they are known to be
+// the same: their checksum is expected or the revision is a commit. The
+// cache entry is locked until the archive is unlocked, so it isn't pruned
+// while it is expanded.
+func download(source, revision, workDir string, opts Options) (*downloaded, error) {
+	dir, err := cache.Path(cache.KindArchive, source, revision)
+	if err != nil {
+		dir = ""
+	}
+	unlock := func() {}
+	if dir != "" {
+		if unlock, err = cache.Lock(dir); err != nil {
+			return nil, err
+		}
+	}
+	archive, err := downloadTo(dir, source, revision, workDir, opts)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	archive.unlock = unlock
+	return archive, nil
+}
+
+// downloadTo downloads the archive to the cache entry in dir, or to a
+// temporary file in the work directory without one
+func downloadTo(dir, source, revision, workDir string, opts Options) (*downloaded, error) {
+	expected := NormalizeSHA256(opts.SHA256)
+	cached := filepath.Join(dir, "archive")
+	if dir != "" && (expected != "" || IsCommit(revision)) {
+		if sum, err := fileSHA256(cached); err == nil && (expected == "" || sum == expected) {
+			if opts.Verbose {
+				fmt.Println("Using cached download of", auth.Redact(source))
+			}
+			if err := cache.Touch(cache.Entry{Kind: cache.KindArchive, URL: source, Revision: revision, Dir: dir}); err != nil {
+				return nil, errors.Wrapf(err, "unable to record the cached download of %s", auth.Redact(source))
+			}
+			return &downloaded{name: cached, sha256: sum}, nil
+		}
+	}
+
+	tmpDir := workDir
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, errors.Wrap(err, "unable to create the cache directory")
+		}
+		tmpDir = dir
+	}
+	tmp, err := ioutil.TempFile(tmpDir, ".download-")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create temporary file for download")
+	}
+	tmp.Close()
+	archive := &downloaded{name: tmp.Name(), temporary: true}
+
+	if opts.Verbose {
+		fmt.Println("Downloading", auth.Redact(source))
+	}
+	archive.contentType, archive.sha256, err = withHTTP(source, archive.name, opts.progress)
+	if err == nil && expected != "" && expected != archive.sha256 {
+		err = fmt.Errorf("checksum of %s does not match: expected sha256 %s, got %s", auth.Redact(source), expected, archive.sha256)
+	}
+	if err != nil {
+		os.Remove(archive.name)
+		if dir != "" {
+			// only if nothing was cached before
+			os.Remove(dir)
+		}
+		return nil, err
+	}
+	if dir != "" && os.Rename(archive.name, cached) == nil {
+		archive.name, archive.temporary = cached, false
+		if err := cache.Touch(cache.Entry{Kind: cache.KindArchive, URL: source, Revision: revision, Dir: dir}); err != nil {
+			return nil, errors.Wrapf(err, "unable to record the cached download of %s", auth.Redact(source))
+		}
+	}
+	return archive, nil
+}
+
+func fileSHA256(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// NormalizeSHA256 turns a checksum into lower case hex without a sha256:
+// prefix
+func NormalizeSHA256(sum string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(sum)), "sha256:")
+}
+
+// gitHubArchive is the URL of the zip archive GitHub offers for a revision
+// of a repository
+func gitHubArchive(repo, version string) string {
+	u, err := ParseGitURL(repo)
+	if err != nil || strings.Count(strings.Trim(u.Path, "/"), "/") != 1 {
+		return repo
+	}
+	return fmt.Sprintf("https://github.com/%s/archive/%s.zip",
+		strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git"), version)
+}
+
+// withHTTP saves the response to the destination and returns its content
+// type and sha256 checksum. The request is authorized with the credentials
+// for the URL. Error responses and truncated downloads fail.
+func withHTTP(url, destination string, progress func(Progress)) (string, string, error) {
+	name := auth.Redact(url)
+	out, err := os.Create(destination)
+	if err != nil {
+		return "", "", errors.Wrap(err, "unable to create "+destination)
+	}
+	defer out.Close()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", "", errors.Wrap(err, "invalid URL "+name)
+	}
+	auth.Authorize(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", errors.New("unable to download " + name + ": " + auth.Redact(err.Error()))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", "", fmt.Errorf("unable to download %s: %s", name, resp.Status)
+	}
+
+	hash := sha256.New()
+	counter := &progressWriter{total: resp.ContentLength, progress: progress}
+	n, err := io.Copy(io.MultiWriter(out, hash, counter), resp.Body)
+	if err != nil {
+		return "", "", errors.Wrap(err, "unable to save "+name)
+	}
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		return "", "", fmt.Errorf("unable to download %s: got %d of %d bytes", name, n, resp.ContentLength)
+	}
+	if err := out.Close(); err != nil {
+		return "", "", errors.Wrap(err, "unable to save "+name)
+	}
+	return resp.Header.Get("Content-Type"), fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// progressWriter reports the bytes written to it
+type progressWriter struct {
+	done, total int64
+	progress    func(Progress)
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	w.done += int64(len(b))
+	total := w.total
+	if total < 0 {
+		total = 0
+	}
+	w.progress(Progress{Done: w.done, Total: total, Unit: "bytes"})
+	return len(b), nil
+}
+
+// topDir is the name of the single directory at the top of an expanded
+// archive, like the directory named after the repository and revision in
+// archives from GitHub. It is empty if the archive has anything else at the
+// top.
+func topDir(dir string) (string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 1 && files[0].IsDir() {
+		return files[0].Name(), nil
+	}
+	return "", nil
+}
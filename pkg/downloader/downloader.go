@@ -1,107 +1,91 @@
 package downloader
 
 import (
-	"archive/zip"
 	"bufio"
 	"bytes"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"text/template"
 
 	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/auth"
+	"github.com/solo-io/thetool/pkg/cache"
 )
 
 var (
-	githubPattern = regexp.MustCompile("https://github.com/([^/]+)/(.+)")
 	// based on https://github.com/bazelbuild/bazel/blob/master/tools/build_defs/repo/git.bzl
 	gitTemplate = template.Must(template.New("git").Parse(`set -ex
 ( cd '{{.workDir}}' 
 rm -rf '{{.repoDir}}'
-git clone '{{.remote}}' '{{.repoDir}}'
+{{if .mirror}}git clone --no-checkout '{{.mirror}}' '{{.repoDir}}'{{else}}git clone '{{.remote}}' '{{.repoDir}}'{{end}}
 cd '{{.repoDir}}'
 git reset --hard {{.ref}} || (git fetch origin {{.ref}}:{{.ref}} && git reset --hard {{.ref}})
-git clean -xdf 
+{{if .mirror}}git remote set-url origin '{{.remote}}'
+{{end}}git clean -xdf 
 git submodule update --init --checkout --force )
 `))
 )
 
 // Checks to see if the URL format is supported
 func SupportedURL(repoURL string) bool {
-	return strings.HasSuffix(repoURL, ".git") ||
-		strings.HasPrefix(repoURL, "http")
+	_, err := Lookup(repoURL)
+	return err == nil
 }
 
-// Download fetches the feature from its repository and saves it to the folder
+// Download fetches the feature from its repository and saves it to the folder.
+// Local repositories are used in place and only checked.
 func Download(repoURL, commitHash, folder string, verbose bool) error {
-	if strings.HasSuffix(repoURL, ".git") {
-		return withGit(repoURL, commitHash, folder, verbose)
-	}
-
-	if strings.HasPrefix(repoURL, "http") {
-		source := handleGitHub(repoURL, commitHash)
-		srcURL, err := url.Parse(source)
-		if err != nil {
-			return errors.Wrap(err, "invalid repository URL")
-		}
-		filename := path.Base(srcURL.Path)
-		destination := path.Join(folder, filename)
-		err = withHTTP(handleGitHub(repoURL, commitHash), destination)
-		if err != nil {
-			return err
-		}
-		// expand (for now we will assume everything is zip file)
-		return expand(folder, filename)
-	}
-
-	return fmt.Errorf("unsupported repository scheme %s (should either end in '.git' or be HTTP/S URL)", repoURL)
+	_, err := Fetch(repoURL, commitHash, folder, Options{Verbose: verbose})
+	return err
 }
 
-func withHTTP(url, destination string) error {
-	out, err := os.Create(destination)
-	if err != nil {
-		return errors.Wrap(err, "unable to create "+destination)
-	}
-	defer out.Close()
+// gitFetcher clones git repositories and checks out the revision
+type gitFetcher struct{}
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return errors.Wrap(err, "unable to download "+url)
+func (gitFetcher) Fetch(repoURL, revision, workDir string, opts Options) (*Result, error) {
+	if err := withGit(repoURL, revision, workDir, opts); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	_, err = io.Copy(out, resp.Body)
+	root := filepath.Join(workDir, RepoDir(repoURL))
+	out, err := git(root, "rev-parse", "HEAD")
 	if err != nil {
-		return errors.Wrap(err, "unable to save "+url)
+		return nil, errors.Wrapf(err, "unable to find the commit checked out for %s", repoURL)
 	}
-	return nil
+	return &Result{Revision: strings.TrimSpace(string(out)), Root: root}, nil
 }
 
-// withGit - uses Git SSH to download the repository
-func withGit(url, commit, folder string, verbose bool) error {
+// withGit - uses Git SSH to download the repository. The repository is
+// cloned from its mirror in the shared cache if the cache is available.
+func withGit(url, commit, folder string, opts Options) error {
 	var out bytes.Buffer
 	data := map[string]string{
 		"workDir": folder,
-		"repoDir": RepoDir(url),
+		"repoDir": filepath.ToSlash(RepoDir(url)),
 		"remote":  url,
 		"ref":     commit,
 	}
+	if _, err := cache.Dir(); err == nil {
+		m, unlock, err := mirror(url, commit, opts)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		data["mirror"] = m
+	}
 	if err := gitTemplate.Execute(&out, data); err != nil {
 		return errors.Wrap(err, "unable to create git script")
 	}
 	script := out.String()
+	verbose := opts.Verbose
 	if verbose {
-		fmt.Println(script)
+		fmt.Println(auth.Redact(script))
 	}
 
 	cmd := exec.Command("bash", "-c", script)
+	cmd.Env = auth.GitEnv(url)
 	if verbose {
 		cmdStdout, err := cmd.StdoutPipe()
 		if err != nil {
@@ -110,7 +94,7 @@ func withGit(url, commit, folder string, verbose bool) error {
 		stdoutScanner := bufio.NewScanner(cmdStdout)
 		go func() {
 			for stdoutScanner.Scan() {
-				fmt.Println("bash: " + stdoutScanner.Text())
+				fmt.Println("bash: " + auth.Redact(stdoutScanner.Text()))
 			}
 		}()
 
@@ -121,7 +105,7 @@ func withGit(url, commit, folder string, verbose bool) error {
 		stderrScanner := bufio.NewScanner(cmdStderr)
 		go func() {
 			for stderrScanner.Scan() {
-				fmt.Println("bash: " + stderrScanner.Text())
+				fmt.Println("bash: " + auth.Redact(stderrScanner.Text()))
 			}
 		}()
 	}
@@ -136,62 +120,55 @@ func withGit(url, commit, folder string, verbose bool) error {
 	return nil
 }
 
-func handleGitHub(repo, version string) string {
-	result := githubPattern.FindAllStringSubmatch(repo, -1)
-	if len(result) != 1 {
-		return repo
+// RepoDir is the directory, relative to the work directory, the repository
+// is downloaded to. It is made of the host and the path of the URL, for
+// example github.com/solo-io/gloo, so repositories with the same name from
+// different owners or servers don't overwrite each other. HTTPS, SSH and
+// scp-like URLs of the same repository share the directory.
+func RepoDir(remoteURL string) string {
+	host, p := "file", remoteURL
+	if u, err := ParseGitURL(remoteURL); err == nil {
+		p = u.Path
+		if u.Host != "" {
+			// a port keeps servers on the same machine apart
+			host = u.Host
+			if u.Port != "" {
+				host += "_" + u.Port
+			}
+		}
 	}
-	if len(result[0]) != 3 {
-		return repo
+	var segments []string
+	for _, s := range strings.Split(host+"/"+p, "/") {
+		s = unsafeSegment.ReplaceAllString(s, "_")
+		if s == "" || s == "." || s == ".." {
+			continue
+		}
+		segments = append(segments, s)
 	}
-	return fmt.Sprintf("https://github.com/%s/%s/archive/%s.zip",
-		result[0][1], strings.TrimSuffix(result[0][2], ".git"), version)
-}
-
-func expand(folder, filename string) error {
-	destination := path.Join(folder, filename)
-	r, err := zip.OpenReader(destination)
-	if err != nil {
-		return errors.Wrap(err, "unable to expand the zip file "+filename)
+	last := len(segments) - 1
+	if name := trimExtension(segments[last]); name != "" {
+		segments[last] = name
 	}
-	defer r.Close()
+	return filepath.Join(segments...)
+}
 
-	for _, zf := range r.File {
-		rc, err := zf.Open()
-		if err != nil {
-			return errors.Wrap(err, "unable to expand the zip file "+filename)
-		}
-		defer rc.Close()
+// LegacyRepoDir is the directory repositories were downloaded to before
+// RepoDir included the host and the owner: only the name of the repository.
+// It is used to move the repositories of older workspaces.
+func LegacyRepoDir(remoteURL string) string {
+	return trimExtension(remoteURL[strings.LastIndex(remoteURL, "/")+1:])
+}
 
-		zpath := filepath.Join(folder, zf.Name)
-		if zf.FileInfo().IsDir() {
-			os.MkdirAll(zpath, os.ModePerm)
-		} else {
-			var zdir string
-			if lastIndex := strings.LastIndex(zpath, string(os.PathSeparator)); lastIndex > -1 {
-				zdir = zpath[:lastIndex]
-			}
-			err = os.MkdirAll(zdir, os.ModePerm)
-			if err != nil {
-				return errors.Wrap(err, "unable to expand the zip file "+filename)
-			}
-			f, err := os.OpenFile(zpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, zf.Mode())
-			if err != nil {
-				return errors.Wrapf(err, "unable to create %s while expanding %s", zf.Name, filename)
-			}
-			defer f.Close()
+var unsafeSegment = regexp.MustCompile(`[^A-Za-z0-9._@+-]+`)
 
-			_, err = io.Copy(f, rc)
-			if err != nil {
-				return errors.Wrapf(err, "unable to write %s while expanding %s", zf.Name, filename)
-			}
+// trimExtension removes the extension of archives and of bare git
+// repositories from the name
+func trimExtension(repo string) string {
+	for _, e := range extensions {
+		if strings.HasSuffix(strings.ToLower(repo), e.ext) {
+			return repo[:len(repo)-len(e.ext)]
 		}
 	}
-	return nil
-}
-
-func RepoDir(remoteURL string) string {
-	repo := remoteURL[strings.LastIndex(remoteURL, "/")+1:]
 	ext := filepath.Ext(repo)
 	if ext != "" {
 		return repo[:len(repo)-len(ext)]
@@ -0,0 +1,413 @@
+package downloader
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ulikunitz/xz"
+)
+
+// Archive formats. Compressed formats are expected to contain a tar archive.
+const (
+	formatZip   = "zip"
+	formatTar   = "tar"
+	formatGzip  = "gzip"
+	formatXz    = "xz"
+	formatBzip2 = "bzip2"
+)
+
+var (
+	magicNumbers = []struct {
+		offset int
+		magic  []byte
+		format string
+	}{
+		{0, []byte("PK\x03\x04"), formatZip},
+		{0, []byte("PK\x05\x06"), formatZip}, // empty zip archive
+		{0, []byte{0x1f, 0x8b}, formatGzip},
+		{0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, formatXz},
+		{0, []byte("BZh"), formatBzip2},
+		{257, []byte("ustar"), formatTar},
+	}
+	extensions = []struct {
+		ext    string
+		format string
+	}{
+		{".zip", formatZip},
+		{".tar", formatTar},
+		{".tar.gz", formatGzip},
+		{".tgz", formatGzip},
+		{".tar.xz", formatXz},
+		{".txz", formatXz},
+		{".tar.bz2", formatBzip2},
+		{".tbz2", formatBzip2},
+		{".tbz", formatBzip2},
+	}
+	contentTypes = map[string]string{
+		"application/zip":              formatZip,
+		"application/x-zip-compressed": formatZip,
+		"application/x-tar":            formatTar,
+		"application/gzip":             formatGzip,
+		"application/x-gzip":           formatGzip,
+		"application/x-xz":             formatXz,
+		"application/x-bzip2":          formatBzip2,
+	}
+)
+
+// detectFormat finds the format of the downloaded archive from its magic
+// number. Old tar archives don't have one, so the extension of the URL and
+// the content type of the response are used next.
+func detectFormat(filename, source, contentType string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	header := make([]byte, 512)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	header = header[:n]
+	for _, m := range magicNumbers {
+		if len(header) >= m.offset+len(m.magic) && bytes.Equal(header[m.offset:m.offset+len(m.magic)], m.magic) {
+			return m.format, nil
+		}
+	}
+
+	name := source
+	if u, err := url.Parse(source); err == nil {
+		name = u.Path
+	}
+	name = strings.ToLower(name)
+	for _, e := range extensions {
+		if strings.HasSuffix(name, e.ext) {
+			return e.format, nil
+		}
+	}
+	if t, _, err := mime.ParseMediaType(contentType); err == nil {
+		if format, ok := contentTypes[t]; ok {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown archive format (content type %q); supported are zip, tar, tar.gz, tar.xz and tar.bz2", contentType)
+}
+
+// expand extracts the archive into the folder
+func expand(archive, format, folder string) error {
+	x := extractor{root: folder}
+	if format == formatZip {
+		if err := expandZip(archive, x); err != nil {
+			return err
+		}
+		return x.checkLinks()
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = bufio.NewReader(f)
+	switch format {
+	case formatTar:
+	case formatGzip:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case formatXz:
+		if r, err = xz.NewReader(r); err != nil {
+			return err
+		}
+	case formatBzip2:
+		r = bzip2.NewReader(r)
+	default:
+		return fmt.Errorf("unsupported archive format %s", format)
+	}
+	if err := expandTar(r, x); err != nil {
+		return err
+	}
+	return x.checkLinks()
+}
+
+func expandZip(archive string, x extractor) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, zf := range r.File {
+		if err := expandZipEntry(zf, x); err != nil {
+			return errors.Wrapf(err, "unable to expand %s", zf.Name)
+		}
+	}
+	return nil
+}
+
+func expandZipEntry(zf *zip.File, x extractor) error {
+	mode := zf.Mode()
+	if mode.IsDir() {
+		return x.dir(zf.Name)
+	}
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if mode&os.ModeSymlink != 0 {
+		// the content of a symbolic link is its target
+		target, err := ioutil.ReadAll(io.LimitReader(rc, 4096))
+		if err != nil {
+			return err
+		}
+		return x.symlink(zf.Name, string(target))
+	}
+	return x.file(zf.Name, rc, mode)
+}
+
+func expandTar(r io.Reader, x extractor) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = x.dir(hdr.Name)
+		case tar.TypeReg, tar.TypeRegA:
+			err = x.file(hdr.Name, tr, hdr.FileInfo().Mode())
+		case tar.TypeSymlink:
+			err = x.symlink(hdr.Name, hdr.Linkname)
+		case tar.TypeLink:
+			err = x.link(hdr.Name, hdr.Linkname)
+		default:
+			// devices, fifos and the global headers of git archive have
+			// nothing for a build
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to expand %s", hdr.Name)
+		}
+	}
+}
+
+// extractor creates the entries of an archive below its root. It refuses
+// entries that would end up outside of the root, directly or through a
+// symbolic link, so a malicious archive can't overwrite other files.
+type extractor struct {
+	root string
+}
+
+// path is where the entry goes. Absolute names and names escaping the root
+// are refused, as are entries below a symbolic link.
+func (x extractor) path(name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" || strings.HasPrefix(name, `\`) {
+		return "", fmt.Errorf("absolute path %s in archive", name)
+	}
+	p := filepath.Join(x.root, name)
+	rel, err := filepath.Rel(x.root, p)
+	if err != nil || rel == "." || !within(x.root, p) {
+		return "", fmt.Errorf("%s is outside of the archive", name)
+	}
+	for dir := filepath.Dir(rel); dir != "."; dir = filepath.Dir(dir) {
+		if fi, err := os.Lstat(filepath.Join(x.root, dir)); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("%s is below the symbolic link %s", name, dir)
+		}
+	}
+	return p, nil
+}
+
+func (x extractor) dir(name string) error {
+	p, err := x.path(name)
+	if err != nil {
+		if strings.Trim(filepath.ToSlash(name), "/.") == "" {
+			// the archive root itself
+			return nil
+		}
+		return err
+	}
+	return os.MkdirAll(p, 0755)
+}
+
+// file writes a file with the permissions from the archive and closes it
+// right away, so large archives don't run out of file descriptors
+func (x extractor) file(name string, r io.Reader, mode os.FileMode) error {
+	p, err := x.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if err := x.replace(p, name); err != nil {
+		return err
+	}
+	// the entry was removed, so O_EXCL makes sure no link is followed
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// symlink creates a relative symbolic link that stays within the root
+func (x extractor) symlink(name, target string) error {
+	p, err := x.path(name)
+	if err != nil {
+		return err
+	}
+	target = filepath.FromSlash(target)
+	if filepath.IsAbs(target) || filepath.VolumeName(target) != "" {
+		return fmt.Errorf("symbolic link to absolute path %s", target)
+	}
+	if !within(x.root, filepath.Join(filepath.Dir(p), target)) {
+		return fmt.Errorf("symbolic link to %s outside of the archive", target)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if err := x.checkTarget(filepath.Dir(p), target); err != nil {
+		return err
+	}
+	if err := x.replace(p, name); err != nil {
+		return err
+	}
+	return os.Symlink(target, p)
+}
+
+// checkTarget follows the target of a link created in dir on the file
+// system. The target must stay within the root at every step and may not go
+// through another link, whose own target would change where it ends up.
+func (x extractor) checkTarget(dir, target string) error {
+	root, err := filepath.EvalSymlinks(x.root)
+	if err != nil {
+		return err
+	}
+	current, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	parts := strings.Split(target, string(filepath.Separator))
+	for i, part := range parts {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+		default:
+			current = filepath.Join(current, part)
+			fi, err := os.Lstat(current)
+			if err == nil && fi.Mode()&os.ModeSymlink != 0 && i != len(parts)-1 {
+				return fmt.Errorf("symbolic link to %s goes through the symbolic link %s", target, part)
+			}
+		}
+		if !within(root, current) {
+			return fmt.Errorf("symbolic link to %s outside of the archive", target)
+		}
+	}
+	return nil
+}
+
+// replace removes what an entry of the archive replaces. Files and links are
+// replaced; a directory isn't, so a path that was checked to be a directory
+// can't become a link.
+func (x extractor) replace(p, name string) error {
+	fi, err := os.Lstat(p)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("%s replaces a directory", name)
+	}
+	return os.Remove(p)
+}
+
+// link creates a hard link to a file extracted before
+func (x extractor) link(name, target string) error {
+	p, err := x.path(name)
+	if err != nil {
+		return err
+	}
+	t, err := x.path(target)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Lstat(t)
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("hard link to %s, which is not a regular file", target)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if err := x.replace(p, name); err != nil {
+		return err
+	}
+	return os.Link(t, p)
+}
+
+// checkLinks makes sure no symbolic link resolves to a path outside of the
+// root. Links to other links are only checked once all of them exist.
+func (x extractor) checkLinks() error {
+	root, err := filepath.EvalSymlinks(x.root)
+	if err != nil {
+		return err
+	}
+	return filepath.Walk(x.root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return err
+		}
+		resolved, err := filepath.EvalSymlinks(p)
+		if os.IsNotExist(err) {
+			// dangling links point nowhere
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !within(root, resolved) {
+			os.Remove(p)
+			rel, _ := filepath.Rel(x.root, p)
+			return fmt.Errorf("symbolic link %s resolves to a path outside of the archive", rel)
+		}
+		return nil
+	})
+}
+
+// within checks if the path is the directory or below it
+func within(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
@@ -0,0 +1,115 @@
+package downloader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Result describes a fetched repository
+type Result struct {
+	// Revision is the revision that was fetched, resolved as far as the
+	// fetcher can: a commit for git, the content hash for local directories
+	// and the requested version for archives
+	Revision string
+	// Root is the directory with the content of the repository
+	Root string
+	// SHA256 is the checksum of the downloaded archive; it is empty for
+	// repositories that are not downloaded as a single file
+	SHA256 string
+}
+
+// Options are the settings of a fetch
+type Options struct {
+	Verbose bool
+	// SHA256 is the expected checksum of the archive. Fetchers downloading
+	// archives fail before expanding one that doesn't match.
+	SHA256 string
+	// Progress, if set, is called as the download makes progress. It may be
+	// called often and from another goroutine.
+	Progress func(Progress)
+}
+
+// Progress is how much of a repository was downloaded
+type Progress struct {
+	Done int64
+	// Total is zero if it is not known
+	Total int64
+	// Unit is bytes for archives and objects for git repositories
+	Unit string
+}
+
+func (o Options) progress(p Progress) {
+	if o.Progress != nil {
+		o.Progress(p)
+	}
+}
+
+// Fetcher downloads repositories of one kind into the work directory
+type Fetcher interface {
+	Fetch(repoURL, revision, workDir string, opts Options) (*Result, error)
+}
+
+const (
+	// FileKey is the registry key for repositories that are directories on
+	// this machine
+	FileKey = "file"
+	// GitKey is the registry key for git URLs: SSH, scp-like, git:// and
+	// URLs ending in .git
+	GitKey = "git"
+)
+
+var fetchers = make(map[string]Fetcher)
+
+func init() {
+	Register(FileKey, localFetcher{})
+	Register(GitKey, gitFetcher{})
+	Register("http", archiveFetcher{})
+	Register("https", archiveFetcher{})
+	Register("github.com", archiveFetcher{archiveURL: gitHubArchive})
+}
+
+// Register adds a fetcher for repositories with the given URL scheme or
+// host, replacing the fetcher registered before. It is meant to be called
+// from init functions.
+func Register(key string, f Fetcher) {
+	fetchers[key] = f
+}
+
+// Lookup finds the fetcher for a repository. Local directories use the
+// FileKey fetcher, and SSH, scp-like and git:// URLs and URLs ending in .git
+// the GitKey fetcher; other URLs use the fetcher registered for their host,
+// or else for their scheme.
+func Lookup(repoURL string) (Fetcher, error) {
+	var keys []string
+	u, err := ParseGitURL(repoURL)
+	switch {
+	case IsLocal(repoURL):
+		keys = []string{FileKey}
+	case strings.HasSuffix(repoURL, ".git") || (err == nil && u.Remote()):
+		keys = []string{GitKey}
+	case err == nil:
+		keys = []string{u.Host, u.Scheme}
+	}
+	for _, k := range keys {
+		if f, ok := fetchers[k]; ok && k != "" {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported repository %s (should be a git URL such as https://host/owner/repo.git, ssh://host/owner/repo, git@host:owner/repo or git://host/owner/repo, an HTTP/S URL or a local directory)", repoURL)
+}
+
+// Fetch downloads the revision of the repository with its fetcher
+func Fetch(repoURL, revision, workDir string, opts Options) (*Result, error) {
+	f, err := Lookup(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	res, err := f.Fetch(repoURL, revision, workDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.SHA256 != "" && res.SHA256 == "" {
+		return nil, fmt.Errorf("repository %s is not downloaded as an archive and can't be checked with a sha256 checksum", repoURL)
+	}
+	return res, nil
+}
@@ -0,0 +1,169 @@
+package downloader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/auth"
+)
+
+var (
+	fullCommitPattern  = regexp.MustCompile("^[0-9a-f]{40}$")
+	shortCommitPattern = regexp.MustCompile("^[0-9a-f]{4,39}$")
+)
+
+// IsCommit checks if the ref is a full commit hash
+func IsCommit(ref string) bool {
+	return fullCommitPattern.MatchString(ref)
+}
+
+// ResolveRef resolves a branch, tag or (short) commit hash in the remote
+// repository to a full commit hash. Refs for HTTP archives that are not
+// git repositories are returned unchanged.
+func ResolveRef(repoURL, ref string) (string, error) {
+	if IsCommit(ref) {
+		return ref, nil
+	}
+	refs, err := lsRemote(repoURL)
+	if err != nil {
+		if !strings.HasSuffix(repoURL, ".git") {
+			// not a git repository; the ref is used as is for the archive
+			return ref, nil
+		}
+		return "", err
+	}
+	for _, name := range []string{
+		ref,
+		"refs/heads/" + ref,
+		"refs/tags/" + ref + "^{}", // annotated tags point to the tag object
+		"refs/tags/" + ref,
+	} {
+		if commit, ok := refs[name]; ok {
+			return commit, nil
+		}
+	}
+	if shortCommitPattern.MatchString(ref) {
+		return revParse(repoURL, ref)
+	}
+	return "", fmt.Errorf("unable to find branch, tag or commit %s in %s", ref, repoURL)
+}
+
+// lsRemote lists the refs in the remote repository
+func lsRemote(repoURL string) (map[string]string, error) {
+	out, err := gitRemote(repoURL, "", "ls-remote", repoURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list references in %s", repoURL)
+	}
+	refs := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			refs[fields[1]] = fields[0]
+		}
+	}
+	return refs, nil
+}
+
+// revParse expands a short commit hash using a temporary bare clone as
+// git servers don't allow looking up abbreviated hashes remotely
+func revParse(repoURL, ref string) (string, error) {
+	dir, err := ioutil.TempDir("", "thetool-resolve")
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create temporary directory")
+	}
+	defer os.RemoveAll(dir)
+	if _, err := gitRemote(repoURL, "", "clone", "--quiet", "--bare", repoURL, dir); err != nil {
+		return "", errors.Wrapf(err, "unable to clone %s", repoURL)
+	}
+	out, err := git(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unable to find branch, tag or commit %s in %s", ref, repoURL)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// git runs git in the given directory and returns its output
+func git(dir string, args ...string) ([]byte, error) {
+	return runGit(nil, dir, args...)
+}
+
+// gitRemote runs git with the credentials for the remote repository
+func gitRemote(repoURL, dir string, args ...string) ([]byte, error) {
+	return runGit(auth.GitEnv(repoURL), dir, args...)
+}
+
+func runGit(env []string, dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v %s", args[0], err, auth.Redact(strings.TrimSpace(stderr.String())))
+	}
+	return out, nil
+}
+
+// RefStatus describes how far a commit is behind the newest commit on a ref
+type RefStatus struct {
+	Behind int
+	Latest string
+	Date   time.Time
+}
+
+// CompareRef compares the commit with the newest commit on the branch or
+// tag in the remote repository. An empty ref uses the default branch. The
+// branches and tags are fetched into the mirror of the repository in the
+// shared cache.
+func CompareRef(repoURL, commit, ref string) (*RefStatus, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	// without a commit to look for, the mirror is always fetched
+	dir, unlock, err := mirror(repoURL, "", Options{})
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if !hasCommit(dir, commit) && IsCommit(commit) {
+		// commits that are not on any branch or tag, if the server allows
+		// fetching them
+		gitRemote(repoURL, dir, "fetch", "--quiet", "origin", commit)
+	}
+	out, err := git(dir, "log", "-1", "--format=%H %cI", ref+"^{commit}", "--")
+	if err != nil {
+		return nil, fmt.Errorf("unable to find branch or tag %s in %s", ref, repoURL)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected output from git log: %s", out)
+	}
+	status := &RefStatus{Latest: fields[0]}
+	status.Date, err = time.Parse(time.RFC3339, fields[1])
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse commit date")
+	}
+	if _, err := git(dir, "cat-file", "-e", commit+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unable to find commit %s in %s", commit, repoURL)
+	}
+	out, err = git(dir, "rev-list", "--count", commit+".."+status.Latest)
+	if err != nil {
+		return nil, err
+	}
+	status.Behind, err = strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse commit count")
+	}
+	return status, nil
+}
@@ -0,0 +1,166 @@
+package downloader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/solo-io/thetool/pkg/cache"
+)
+
+// testRepo creates a git repository with two commits on master, a
+// lightweight tag on the first and an annotated tag on the second
+func testRepo(t *testing.T) (string, []string) {
+	dir, err := ioutil.TempDir("", "thetool-git")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	run := func(args ...string) string {
+		out, err := git(dir, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "--quiet")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "test")
+	run("checkout", "--quiet", "-b", "master")
+	var commits []string
+	for i, content := range []string{"one", "two"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", "file.txt")
+		run("commit", "--quiet", "-m", content)
+		commits = append(commits, run("rev-parse", "HEAD"))
+		if i == 0 {
+			run("tag", "v1")
+		} else {
+			run("tag", "-a", "-m", "release", "v2")
+		}
+	}
+	return dir, commits
+}
+
+func TestResolveRef(t *testing.T) {
+	dir, commits := testRepo(t)
+	defer os.RemoveAll(dir)
+
+	cases := [][]string{
+		{"master", commits[1]},
+		{"v1", commits[0]},
+		{"v2", commits[1]},
+		{commits[0][:7], commits[0]},
+		{commits[1], commits[1]},
+	}
+	for _, c := range cases {
+		resolved, err := ResolveRef(dir, c[0])
+		if err != nil {
+			t.Errorf("unable to resolve %s: %v", c[0], err)
+			continue
+		}
+		if resolved != c[1] {
+			t.Errorf("expected %s to resolve to %s got %s", c[0], c[1], resolved)
+		}
+	}
+
+	if _, err := ResolveRef(dir, "no-such-branch"); err == nil {
+		t.Error("expected unknown ref to fail")
+	}
+}
+
+func TestCompareRef(t *testing.T) {
+	dir, commits := testRepo(t)
+	defer os.RemoveAll(dir)
+
+	status, err := CompareRef("file://"+dir, commits[0], "master")
+	if err != nil {
+		t.Fatal("unable to compare with master", err)
+	}
+	if status.Behind != 1 || status.Latest != commits[1] {
+		t.Errorf("expected to be 1 commit behind %s got %+v", commits[1], status)
+	}
+	if status.Date.IsZero() {
+		t.Error("expected the date of the newest commit")
+	}
+
+	status, err = CompareRef("file://"+dir, commits[0], "v1")
+	if err != nil {
+		t.Fatal("unable to compare with v1", err)
+	}
+	if status.Behind != 0 {
+		t.Errorf("expected to be up to date with v1 got %+v", status)
+	}
+
+	if _, err := CompareRef("file://"+dir, strings.Repeat("0", 40), ""); err == nil {
+		t.Error("expected unknown commit to fail")
+	}
+
+	// the cached mirror is fetched again
+	ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte("three"), 0644)
+	if _, err := git(dir, "commit", "--quiet", "-am", "three"); err != nil {
+		t.Fatal(err)
+	}
+	status, err = CompareRef("file://"+dir, commits[0], "master")
+	if err != nil {
+		t.Fatal("unable to compare with master", err)
+	}
+	if status.Behind != 2 {
+		t.Errorf("expected the new commit to be fetched got %+v", status)
+	}
+	if m, _ := cache.Path(cache.KindGit, "file://"+dir, ""); !hasCommit(m, status.Latest) {
+		t.Error("expected the comparison to use the cached mirror")
+	}
+}
+
+func TestMirror(t *testing.T) {
+	dir, commits := testRepo(t)
+	defer os.RemoveAll(dir)
+	work, err := ioutil.TempDir("", "thetool-work")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	defer os.RemoveAll(work)
+	// repositories are cloned into a directory named after the URL
+	if err := os.Symlink(dir, filepath.Join(work, "plugins.git")); err != nil {
+		t.Fatal(err)
+	}
+	remote := "file://" + filepath.ToSlash(work) + "/plugins.git"
+
+	res, err := Fetch(remote, commits[0], work, Options{})
+	if err != nil {
+		t.Fatal("unable to fetch repository", err)
+	}
+	if res.Revision != commits[0] {
+		t.Errorf("expected commit %s, got %s", commits[0], res.Revision)
+	}
+	if out, _ := git(res.Root, "remote", "get-url", "origin"); strings.TrimSpace(string(out)) != remote {
+		t.Errorf("expected origin to be the remote, got %s", out)
+	}
+
+	// new commits are fetched into the mirror
+	ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte("three"), 0644)
+	git(dir, "commit", "--quiet", "-am", "three")
+	out, _ := git(dir, "rev-parse", "HEAD")
+	latest := strings.TrimSpace(string(out))
+	if res, err = Fetch(remote, latest, work, Options{}); err != nil || res.Revision != latest {
+		t.Fatalf("expected the new commit to be fetched, got %v: %v", res, err)
+	}
+	mirror, _ := cache.Path(cache.KindGit, remote, "")
+	if out, err := git(mirror, "cat-file", "-e", latest); err != nil {
+		t.Errorf("expected the mirror to have the new commit: %s %v", out, err)
+	}
+}
+
+func TestParseGitProgress(t *testing.T) {
+	p, ok := parseGitProgress("Receiving objects:  45% (1234/2742), 1.20 MiB | 2.40 MiB/s")
+	if !ok || p != (Progress{Done: 1234, Total: 2742, Unit: "objects"}) {
+		t.Errorf("unexpected progress %+v", p)
+	}
+	if _, ok := parseGitProgress("Resolving deltas: 100% (12/12), done."); ok {
+		t.Error("expected only received objects to be reported")
+	}
+}
@@ -0,0 +1,104 @@
+package downloader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// leftFile lists the legacy directories of the work directory that were
+// reported to be left in place, so they are reported once
+const leftFile = ".thetool-left-in-place"
+
+// MoveLegacyDirs moves the repositories with the given URLs from their
+// LegacyRepoDir to their RepoDir in the work directory. A directory several
+// of the repositories had the same name for is left alone, as is one whose
+// new directory already exists. It returns the URLs of the repositories that
+// are now in their new directory.
+func MoveLegacyDirs(workDir string, urls []string) (map[string]bool, error) {
+	owners := make(map[string]map[string]bool)
+	for _, u := range urls {
+		old := LegacyRepoDir(u)
+		if owners[old] == nil {
+			owners[old] = make(map[string]bool)
+		}
+		owners[old][RepoDir(u)] = true
+	}
+
+	moved := make(map[string]bool)
+	// the legacy directories looked at, and if they were moved
+	dirs := make(map[string]bool)
+	for _, u := range urls {
+		from := filepath.Join(workDir, LegacyRepoDir(u))
+		to := filepath.Join(workDir, RepoDir(u))
+		if from == to {
+			continue
+		}
+		if done, ok := dirs[from]; ok {
+			// another URL of a repository already looked at, which has
+			// the same new directory since the directory has one owner
+			if done {
+				moved[u] = true
+			}
+			continue
+		}
+		dirs[from] = false
+		if fi, err := os.Stat(from); err != nil || !fi.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(to); err == nil {
+			continue
+		}
+		if len(owners[LegacyRepoDir(u)]) > 1 {
+			if err := reportLeft(workDir, LegacyRepoDir(u)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(from, to); err != nil {
+			return nil, errors.Wrapf(err, "unable to move %s to %s", from, to)
+		}
+		fmt.Printf("Moved %s to %s\n", from, to)
+		dirs[from], moved[u] = true, true
+	}
+	return moved, nil
+}
+
+// RelocateRoot returns where a root in the legacy directory of the
+// repository with the URL is once the repository was moved to its new
+// directory, and if it was in the legacy directory
+func RelocateRoot(root, workDir, url string) (string, bool) {
+	from := filepath.Join(workDir, LegacyRepoDir(url))
+	to := filepath.Join(workDir, RepoDir(url))
+	if root == from {
+		return to, true
+	}
+	if strings.HasPrefix(root, from+string(filepath.Separator)) {
+		return to + root[len(from):], true
+	}
+	return "", false
+}
+
+// reportLeft tells that the legacy directory is left in place, unless it
+// was reported before
+func reportLeft(workDir, name string) error {
+	filename := filepath.Join(workDir, leftFile)
+	b, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, reported := range strings.Split(string(b), "\n") {
+		if reported == name {
+			return nil
+		}
+	}
+	fmt.Printf("Leaving %s in place as it may belong to any of several repositories\n", filepath.Join(workDir, name))
+	return ioutil.WriteFile(filename, append(b, name+"\n"...), 0644)
+}
@@ -0,0 +1,180 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"go/build"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	fileScheme = "file://"
+)
+
+// IsLocal checks if the repository is a directory on this machine that is
+// used in place. Paths ending in .git are cloned like any other git
+// repository.
+func IsLocal(repoURL string) bool {
+	if strings.HasSuffix(repoURL, ".git") {
+		return false
+	}
+	return strings.HasPrefix(repoURL, fileScheme) || filepath.IsAbs(repoURL) ||
+		repoURL == "." || repoURL == ".." ||
+		strings.HasPrefix(repoURL, "./") || strings.HasPrefix(repoURL, "../") ||
+		strings.HasPrefix(repoURL, `.\`) || strings.HasPrefix(repoURL, `..\`)
+}
+
+// LocalPath is the absolute path of a local repository
+func LocalPath(repoURL string) (string, error) {
+	return filepath.Abs(strings.TrimPrefix(repoURL, fileScheme))
+}
+
+// LocalImportPath is the Go import path of a local repository: its path
+// below the src directory of a GOPATH entry, or else the import path of the
+// origin of the git checkout it is in, so packages of the repository that
+// import each other keep working. Without either it is local/ followed by
+// the directory name.
+func LocalImportPath(repoURL string) string {
+	p, err := LocalPath(repoURL)
+	if err != nil {
+		p = repoURL
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		gopath = build.Default.GOPATH
+	}
+	for _, dir := range filepath.SplitList(gopath) {
+		if rel, ok := below(filepath.Join(dir, "src"), p); ok {
+			return rel
+		}
+	}
+	if out, err := git(p, "rev-parse", "--show-toplevel"); err == nil {
+		top := strings.TrimSpace(string(out))
+		origin, err := git(p, "remote", "get-url", "origin")
+		if rel, ok := below(top, p); ok && err == nil {
+			if u, err := ParseGitURL(strings.TrimSpace(string(origin))); err == nil && u.Host != "" {
+				return path.Join(u.ImportPath(), rel)
+			}
+		}
+	}
+	return "local/" + filepath.Base(p)
+}
+
+// below returns the slash separated path of p relative to dir, if it is dir
+// or inside of it
+func below(dir, p string) (string, bool) {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// SourceDir is the directory with the source of the repository: the work
+// directory the repository is downloaded to, or the directory itself for
+// local repositories
+func SourceDir(workDir, repoURL string) string {
+	if IsLocal(repoURL) {
+		if p, err := LocalPath(repoURL); err == nil {
+			return p
+		}
+	}
+	return filepath.Join(workDir, RepoDir(repoURL))
+}
+
+// ContentHash is the hash of the files in the directory, including changes
+// that are not committed. It is used as the revision of local repositories.
+func ContentHash(dir string) (string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to read %s", dir)
+	}
+	sort.Strings(files)
+
+	hash := sha256.New()
+	for _, p := range files {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return "", err
+		}
+		info, err := os.Lstat(p)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s %o\n", filepath.ToSlash(rel), info.Mode())
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(p)
+			if err != nil {
+				return "", err
+			}
+			io.WriteString(hash, target)
+			continue
+		}
+		if err := hashFile(hash, p); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func useLocal(repoURL string) error {
+	p, err := LocalPath(repoURL)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		return errors.Wrapf(err, "unable to use local repository %s", repoURL)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("local repository %s is not a directory", repoURL)
+	}
+	return nil
+}
+
+// localFetcher uses directories on this machine in place
+type localFetcher struct{}
+
+func (localFetcher) Fetch(repoURL, revision, workDir string, opts Options) (*Result, error) {
+	if err := useLocal(repoURL); err != nil {
+		return nil, err
+	}
+	root, err := LocalPath(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := ContentHash(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Revision: hash, Root: root}, nil
+}
@@ -0,0 +1,158 @@
+package downloader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/auth"
+	"github.com/solo-io/thetool/pkg/cache"
+)
+
+// mirror returns the bare mirror of the repository in the shared cache,
+// cloning it the first time and fetching from the remote when it doesn't
+// have the commit yet. Workspaces clone from the mirror, which hard-links
+// its objects instead of downloading them again. The mirror stays locked,
+// so other fetches don't update or prune it while the workspace is cloned
+// from it, until the returned function unlocks it.
+func mirror(repoURL, commit string, opts Options) (string, func(), error) {
+	dir, err := cache.Path(cache.KindGit, repoURL, "")
+	if err != nil {
+		return "", nil, err
+	}
+	unlock, err := cache.Lock(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	m, err := updateMirror(dir, repoURL, commit, opts)
+	if err != nil {
+		unlock()
+		return "", nil, err
+	}
+	return m, unlock, nil
+}
+
+// updateMirror clones or fetches the mirror in dir
+func updateMirror(dir, repoURL, commit string, opts Options) (string, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if opts.Verbose {
+			fmt.Println("Caching", auth.Redact(repoURL), "in", dir)
+		}
+		if err := cloneMirror(repoURL, dir, opts.progress); err != nil {
+			return "", err
+		}
+	} else if !hasCommit(dir, commit) {
+		if opts.Verbose {
+			fmt.Println("Updating the cached mirror of", auth.Redact(repoURL))
+		}
+		if err := gitProgress(repoURL, dir, opts.progress, "fetch", "--progress", "--prune", "origin"); err != nil {
+			return "", errors.Wrapf(err, "unable to update the cached mirror of %s; remove it with thetool cache prune --all", repoURL)
+		}
+		if !hasCommit(dir, commit) && IsCommit(commit) {
+			// commits that are not on any branch or tag, if the server
+			// allows fetching them
+			gitRemote(repoURL, dir, "fetch", "--quiet", "origin", commit)
+		}
+	}
+	if err := cache.Touch(cache.Entry{Kind: cache.KindGit, URL: repoURL, Dir: dir}); err != nil {
+		return "", errors.Wrapf(err, "unable to record the cached mirror of %s", repoURL)
+	}
+	return dir, nil
+}
+
+// cloneMirror clones next to the final directory and renames the complete
+// mirror into place, so an interrupted clone is never used
+func cloneMirror(repoURL, dir string, progress func(Progress)) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return errors.Wrap(err, "unable to create the cache directory")
+	}
+	tmp, err := ioutil.TempDir(filepath.Dir(dir), ".clone-")
+	if err != nil {
+		return errors.Wrap(err, "unable to create the cache directory")
+	}
+	defer os.RemoveAll(tmp)
+	if err := gitProgress(repoURL, "", progress, "clone", "--progress", "--mirror", repoURL, tmp); err != nil {
+		return errors.Wrapf(err, "unable to clone %s", repoURL)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		if _, statErr := os.Stat(dir); statErr == nil {
+			// another process cached it first
+			return nil
+		}
+		return errors.Wrapf(err, "unable to move the mirror of %s into the cache", repoURL)
+	}
+	return nil
+}
+
+// hasCommit checks if the mirror has the commit. Branches and tags are
+// always fetched again as they may have moved.
+func hasCommit(dir, commit string) bool {
+	if !IsCommit(commit) {
+		return false
+	}
+	_, err := git(dir, "cat-file", "-e", commit+"^{commit}")
+	return err == nil
+}
+
+var receivingPattern = regexp.MustCompile(`Receiving objects:\s+\d+% \((\d+)/(\d+)\)`)
+
+// gitProgress runs git with the remote repository in the given directory
+// and reports the objects it receives, which git prints on stderr with
+// --progress
+func gitProgress(repoURL, dir string, progress func(Progress), args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = auth.GitEnv(repoURL)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("git %s: %v", args[0], err)
+	}
+	var messages []string
+	scanner := bufio.NewScanner(stderr)
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if p, ok := parseGitProgress(line); ok {
+			progress(p)
+		} else if !strings.Contains(line, "% (") && !strings.HasPrefix(line, "Cloning into") && strings.TrimSpace(line) != "" {
+			messages = append(messages, auth.Redact(line))
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git %s: %v %s", args[0], err, strings.TrimSpace(strings.Join(messages, "\n")))
+	}
+	return nil
+}
+
+func parseGitProgress(line string) (Progress, bool) {
+	m := receivingPattern.FindStringSubmatch(line)
+	if m == nil {
+		return Progress{}, false
+	}
+	done, _ := strconv.ParseInt(m[1], 10, 64)
+	total, _ := strconv.ParseInt(m[2], 10, 64)
+	return Progress{Done: done, Total: total, Unit: "objects"}, true
+}
+
+// scanProgressLines splits lines ending in a carriage return, which git
+// uses to update its progress in place, as well as in a newline
+func scanProgressLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,130 @@
+package downloader
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GitURL is a parsed repository URL. All the ways of writing the URL of a
+// git repository are accepted:
+//   - https://host/owner/repo.git and http://
+//   - ssh://user@host:port/owner/repo.git
+//   - user@host:owner/repo.git, the scp-like syntax of git
+//   - git://host/owner/repo.git
+//   - file:///path/repo.git and plain paths
+type GitURL struct {
+	// Scheme is https, http, ssh, git or file. scp-like URLs use ssh.
+	Scheme string
+	User   string
+	// Host is in lower case, without the port
+	Host string
+	Port string
+	// Path has no leading slash; it keeps any .git suffix
+	Path string
+	// SCP is set for URLs in the scp-like syntax
+	SCP bool
+}
+
+var numeric = regexp.MustCompile(`^[0-9]+$`)
+
+// ParseGitURL parses the URL of a repository. Credentials in the URL are
+// dropped; the auth package finds them when they are needed.
+func ParseGitURL(rawURL string) (*GitURL, error) {
+	s := strings.Replace(strings.TrimSpace(rawURL), `\`, "/", -1)
+	u := &GitURL{}
+	var hostPart string
+	slash := strings.Index(s, "/")
+	switch {
+	case strings.Contains(s, "://"):
+		i := strings.Index(s, "://")
+		u.Scheme, s = strings.ToLower(s[:i]), s[i+3:]
+		hostPart, u.Path = split(s)
+		if u.Scheme == "file" {
+			u.Path = strings.TrimLeft(s, "/")
+			return u, nil
+		}
+	case strings.Index(s, ":") > 1 && (slash == -1 || strings.Index(s, ":") < slash):
+		// user@host:path; a single letter before the colon is a drive
+		i := strings.Index(s, ":")
+		u.Scheme, u.SCP = "ssh", true
+		hostPart, u.Path = s[:i], strings.TrimLeft(s[i+1:], "/")
+	case strings.Contains(s, "@") && slash > strings.Index(s, "@"):
+		// user@host/path
+		u.Scheme = "ssh"
+		hostPart, u.Path = split(s)
+	default:
+		u.Scheme = "file"
+		u.Path = strings.TrimLeft(s, "/")
+		return u, nil
+	}
+
+	if i := strings.LastIndex(hostPart, "@"); i != -1 {
+		u.User, hostPart = hostPart[:i], hostPart[i+1:]
+		if j := strings.Index(u.User, ":"); j != -1 {
+			u.User = u.User[:j]
+		}
+	}
+	if i := strings.LastIndex(hostPart, ":"); i != -1 && !strings.HasSuffix(hostPart, "]") {
+		if port := hostPart[i+1:]; numeric.MatchString(port) || port == "" {
+			hostPart, u.Port = hostPart[:i], port
+		} else {
+			// scp-like path after ssh://, like ssh://host:owner/repo
+			hostPart, u.Path = hostPart[:i], port+"/"+u.Path
+		}
+	}
+	u.Host = strings.ToLower(hostPart)
+	if u.Host == "" {
+		return nil, fmt.Errorf("no host in repository URL %s", rawURL)
+	}
+	return u, nil
+}
+
+func split(s string) (string, string) {
+	if i := strings.Index(s, "/"); i != -1 {
+		return s[:i], strings.TrimLeft(s[i+1:], "/")
+	}
+	return s, ""
+}
+
+// Remote checks if the repository is fetched with git over the network
+// rather than over HTTP
+func (u *GitURL) Remote() bool {
+	return u.Scheme == "ssh" || u.Scheme == "git"
+}
+
+// ImportPath is the Go import path of the repository: the host and the path
+// without the .git suffix, for example gitlab.example.com/group/repo
+func (u *GitURL) ImportPath() string {
+	return u.Host + "/" + strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), ".git")
+}
+
+// CloneURL is the URL to clone the repository with, without credentials.
+// scp-like URLs are written as ssh:// URLs, which every tool understands.
+func (u *GitURL) CloneURL() string {
+	if u.Scheme == "file" {
+		return "file:///" + u.Path
+	}
+	host := u.Host
+	if u.Port != "" {
+		host += ":" + u.Port
+	}
+	if u.Scheme == "ssh" && u.User != "" {
+		host = u.User + "@" + host
+	}
+	return u.Scheme + "://" + host + "/" + u.Path
+}
+
+// SSHBase is the beginning of the SSH URLs of the repositories on the host,
+// for rewriting HTTPS URLs with git's url.<base>.insteadOf. It is git@host:
+// unless the repository is reached through SSH with another user or port.
+func (u *GitURL) SSHBase() string {
+	user := "git"
+	if u.Scheme == "ssh" && u.User != "" {
+		user = u.User
+	}
+	if u.Scheme == "ssh" && u.Port != "" {
+		return fmt.Sprintf("ssh://%s@%s:%s/", user, u.Host, u.Port)
+	}
+	return user + "@" + u.Host + ":"
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/solo-io/thetool/pkg/common"
+	"github.com/solo-io/thetool/pkg/downloader"
 	"github.com/solo-io/thetool/pkg/feature"
 	"github.com/solo-io/thetool/pkg/util"
 )
@@ -28,7 +29,7 @@ func Build(enabled []feature.Feature, verbose, dryRun, cache bool, sshKeyFile, e
 	os.Mkdir(buildDir, 0777)
 	os.Mkdir(filepath.Join(buildDir, "envoy-out"), 0777)
 	data := templateData{}
-	data.Features = envoyFilters(enabled)
+	data.Filters = envoyFilters(enabled)
 	data.EnvoyCommonHash = commonHash
 	data.EnvoyHash = eHash
 	data.EnvoyRepoUser = repoUser
@@ -58,6 +59,7 @@ func Build(enabled []feature.Feature, verbose, dryRun, cache bool, sshKeyFile, e
 	name := "thetool-envoy"
 	args := []string{"run", "-i", "--rm", "--name", name,
 		"-v", filepath.Join(pwd, wDir) + ":/repositories"}
+	args = append(args, localVolumes(enabled)...)
 	if runtime.GOOS == "darwin" {
 		args = append(args, "-v", srcDir+":/source:delegated")
 	} else {
@@ -74,9 +76,18 @@ func Build(enabled []feature.Feature, verbose, dryRun, cache bool, sshKeyFile, e
 		}
 		args = append(args, "-v", v)
 	}
-	if sshKeyFile != "" {
-		args = append(args, common.GetSshKeyArgs(sshKeyFile)...)
+	var repos []string
+	for _, f := range enabled {
+		if !downloader.IsLocal(f.Repository) {
+			repos = append(repos, f.Repository)
+		}
+	}
+	cargs, cleanup, err := common.GetCredentialArgs(sshKeyFile, repos)
+	if err != nil {
+		return err
 	}
+	defer cleanup()
+	args = append(args, cargs...)
 
 	uargs, err := common.GetUidArgs()
 	if err != nil {
@@ -169,11 +180,28 @@ func envoyBuildLog() string {
 	return filepath.Join(bazelDir, "<hash>", logFile)
 }
 
-func envoyFilters(enabled []feature.Feature) []feature.Feature {
-	out := []feature.Feature{}
+// localVolumes mounts the local repositories of the Envoy filters at the
+// same path in the build container
+func localVolumes(enabled []feature.Feature) []string {
+	var args []string
+	seen := make(map[string]bool)
+	for _, f := range enabled {
+		if f.EnvoyDir == "" || !downloader.IsLocal(f.Repository) || seen[f.Repository] {
+			continue
+		}
+		seen[f.Repository] = true
+		if src, err := downloader.LocalPath(f.Repository); err == nil {
+			args = append(args, "-v", src+":"+filepath.ToSlash(src)+":ro")
+		}
+	}
+	return args
+}
+
+func envoyFilters(enabled []feature.Feature) []filter {
+	out := []filter{}
 	for _, f := range enabled {
 		if f.EnvoyDir != "" {
-			out = append(out, f)
+			out = append(out, filter{Name: RepositoryName(f, enabled), Path: path(f)})
 		}
 	}
 	return out
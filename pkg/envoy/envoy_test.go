@@ -0,0 +1,58 @@
+package envoy
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/solo-io/thetool/pkg/feature"
+)
+
+func TestEnvoyFiltersAreUnique(t *testing.T) {
+	enabled := []feature.Feature{
+		{Name: "aws_lambda", EnvoyDir: "aws/envoy", Repository: "https://github.com/solo-io/gloo-plugins.git", Alias: "gloo-plugins"},
+		{Name: "aws_lambda", EnvoyDir: "aws/envoy", Repository: "https://github.com/axhixh/gloo-plugins.git", Alias: "my.fork"},
+		{Name: "nats", EnvoyDir: "nats/envoy", Repository: "https://github.com/solo-io/gloo-plugins.git", Alias: "gloo-plugins"},
+		{Name: "kubernetes", Repository: "https://github.com/solo-io/gloo-plugins.git", Alias: "gloo-plugins"},
+	}
+	filters := envoyFilters(enabled)
+	expected := []string{"gloo_plugins__aws_lambda", "my_fork__aws_lambda", "nats"}
+	if len(filters) != len(expected) {
+		t.Fatalf("expected %d filters got %v", len(expected), filters)
+	}
+	for i, name := range expected {
+		if filters[i].Name != name {
+			t.Errorf("expected repository name %s got %s", name, filters[i].Name)
+		}
+	}
+
+	w := &bytes.Buffer{}
+	if err := fromTemplate(w, workspaceTemplate, templateData{Filters: filters}); err != nil {
+		t.Fatal("unable to generate workspace", err)
+	}
+	for _, name := range expected {
+		if strings.Count(w.String(), `name = "`+name+`"`) != 1 {
+			t.Errorf("expected exactly one local_repository named %s", name)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	cases := []struct {
+		f        feature.Feature
+		expected string
+	}{
+		{feature.Feature{Repository: "https://github.com/solo-io/gloo-plugins.git", EnvoyDir: "aws/envoy"},
+			"/repositories/github.com/solo-io/gloo-plugins/aws/envoy"},
+		{feature.Feature{Repository: "https://github.com/solo-io/gloo-plugins", EnvoyDir: "aws/envoy",
+			Root: "repositories/github.com/solo-io/gloo-plugins/gloo-plugins-1f64f09"},
+			"/repositories/github.com/solo-io/gloo-plugins/gloo-plugins-1f64f09/aws/envoy"},
+		{feature.Feature{Repository: "/home/dev/plugins", EnvoyDir: "aws/envoy", Root: "/home/dev/plugins"},
+			"/home/dev/plugins/aws/envoy"},
+	}
+	for _, c := range cases {
+		if actual := path(c.f); actual != c.expected {
+			t.Errorf("expected %s got %s", c.expected, actual)
+		}
+	}
+}
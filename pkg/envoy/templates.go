@@ -1,7 +1,7 @@
 package envoy
 
 import (
-	"fmt"
+	"path/filepath"
 	"strings"
 	"text/template"
 
@@ -31,7 +31,7 @@ fi
 cat << EOF > build_user.sh
 #!/bin/bash
 set -ex
-PATH="$PATH"
+` + common.CredentialsTemplate + `PATH="$PATH"
 if [ -n "$GIT_SSH_COMMAND"]; then
   GIT_SSH_COMMAND="$GIT_SSH_COMMAND"
 fi
@@ -68,7 +68,7 @@ load(
 envoy_cc_binary(
     name = "envoy",
     repository = "@envoy",
-    deps = [{{range .Features}}
+    deps = [{{range .Filters}}
 		"@{{.Name}}//:filter_lib",{{end}}
 		"@envoy//source/exe:envoy_main_entry_lib",
     ],
@@ -77,10 +77,10 @@ envoy_cc_binary(
 
 	workspaceContent = `workspace(name = "gloo")
 load('@bazel_tools//tools/build_defs/repo:git.bzl', 'git_repository')
-{{range .Features }}
+{{range .Filters }}
 local_repository(
     name = "{{.Name}}",
-    path = "{{. | path}}",
+    path = "{{.Path}}",
 )
 
 {{end}}
@@ -167,38 +167,62 @@ var (
 	buildScriptTemplate *template.Template
 
 	workDir = "repositories"
+
+	bazelNameReplacer = strings.NewReplacer("/", "__", "-", "_", ".", "_")
 )
 
 type templateData struct {
-	Features        []feature.Feature
+	Filters         []filter
 	EnvoyHash       string
 	EnvoyCommonHash string
 	EnvoyRepoUser   string
 }
 
+// filter is an Envoy filter as a Bazel local repository
+type filter struct {
+	Name string
+	Path string
+}
+
 func init() {
 	buildTemplate = template.Must(template.New("build").Parse(buildContent))
-	funcMap := template.FuncMap{
-		"path": path,
-	}
-	workspaceTemplate = template.Must(template.New("workspace").
-		Funcs(funcMap).Parse(workspaceContent))
+	workspaceTemplate = template.Must(template.New("workspace").Parse(workspaceContent))
 
 	buildScriptTemplate = template.Must(template.New("script").Parse(buildScript))
 }
 
+// path is where the feature's Envoy filter is in the build container.
+// Local repositories are mounted at the same path and downloaded ones are in
+// the work directory.
 func path(f feature.Feature) string {
-	if strings.HasSuffix(f.Repository, ".git") {
-		return fmt.Sprintf("/%s/%s/%s", workDir, downloader.RepoDir(f.Repository), f.EnvoyDir)
+	if downloader.IsLocal(f.Repository) {
+		if p, err := downloader.LocalPath(f.Repository); err == nil {
+			return filepath.ToSlash(filepath.Join(p, f.EnvoyDir))
+		}
 	}
-
-	if isGitHubHTTP(f.Repository) {
-		return fmt.Sprintf("/%s/%s-%s/envoy", workDir, f.Name, f.Revision)
+	root := f.Root
+	if root == "" {
+		root = downloader.SourceDir(workDir, f.Repository)
+	}
+	if rel, err := filepath.Rel(workDir, root); err == nil {
+		root = rel
 	}
+	return "/" + filepath.ToSlash(filepath.Join(workDir, root, f.EnvoyDir))
+}
 
-	return fmt.Sprintf("/%s/%s/%s", workDir, downloader.RepoDir(f.Repository), f.EnvoyDir)
+// RepositoryName is the name of the Bazel repository for the feature's
+// Envoy filter. It is the feature name unless another enabled feature has
+// the same name, in which case it is qualified with the repository alias.
+func RepositoryName(f feature.Feature, enabled []feature.Feature) string {
+	for _, e := range enabled {
+		if e.Name == f.Name && e.ID() != f.ID() {
+			return bazelNameReplacer.Replace(f.ID())
+		}
+	}
+	return f.Name
 }
 
-func isGitHubHTTP(url string) bool {
-	return strings.HasPrefix(url, "https://github.com/")
+// Target is the Bazel target of the feature's Envoy filter
+func Target(f feature.Feature, enabled []feature.Feature) string {
+	return "@" + RepositoryName(f, enabled) + "//:filter_lib"
 }
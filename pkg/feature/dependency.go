@@ -0,0 +1,245 @@
+package feature
+
+import (
+	"fmt"
+	"strings"
+)
+
+// graph follows the requires and conflicts relationships declared in the
+// manifests between features
+type graph struct {
+	features []Feature
+}
+
+func newGraph(features []Feature) *graph {
+	return &graph{features: features}
+}
+
+// lookup finds the feature a requires or conflicts entry of the feature at
+// index from refers to. A short name refers to the feature with that name
+// in the same repository before falling back to the other repositories.
+func (g *graph) lookup(from int, name string) (int, error) {
+	for i, f := range g.features {
+		if f.Name == name && f.Repository == g.features[from].Repository {
+			return i, nil
+		}
+	}
+	return Find(g.features, name)
+}
+
+// refersTo checks if any of the names listed by the feature at index from
+// refers to the feature at index to
+func (g *graph) refersTo(from, to int, names []string) bool {
+	for _, name := range names {
+		if i, err := g.lookup(from, name); err == nil && i == to {
+			return true
+		}
+	}
+	return false
+}
+
+// requirements walks the requires relationships starting at the feature at
+// index i and adds every feature reached to out, with the chain of features
+// that led to it. The chain is used to report missing features and cycles.
+func (g *graph) requirements(i int, chain []int, out map[int][]int) error {
+	chain = append(append([]int{}, chain...), i)
+	for _, c := range chain[:len(chain)-1] {
+		if c == i {
+			return fmt.Errorf("dependency cycle: %s", g.formatChain(chain))
+		}
+	}
+	if _, seen := out[i]; seen {
+		return nil
+	}
+	out[i] = chain
+	for _, r := range g.features[i].Requires {
+		j, err := g.lookup(i, r)
+		if err != nil {
+			return fmt.Errorf("%s -> %s: %v", g.formatChain(chain), r, err)
+		}
+		if err := g.requirements(j, chain, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dependents adds the enabled features that directly or indirectly require
+// the feature at index i to out, together with the chain that requires it
+func (g *graph) dependents(i int, chain []int, out map[int][]int) {
+	chain = append([]int{i}, chain...)
+	for j, f := range g.features {
+		if !f.Enabled || !g.refersTo(j, i, f.Requires) {
+			continue
+		}
+		if _, seen := out[j]; seen || containsIndex(chain, j) {
+			continue
+		}
+		out[j] = append([]int{j}, chain...)
+		g.dependents(j, chain, out)
+	}
+}
+
+// conflicts reports whether the features at indexes a and b conflict with
+// each other. Conflicts only need to be declared on one side.
+func (g *graph) conflicts(a, b int) bool {
+	return g.refersTo(a, b, g.features[a].Conflicts) || g.refersTo(b, a, g.features[b].Conflicts)
+}
+
+// name is the short name of the feature at index i unless another
+// repository has a feature with the same name
+func (g *graph) name(i int) string {
+	for j, f := range g.features {
+		if j != i && f.Name == g.features[i].Name {
+			return g.features[i].ID()
+		}
+	}
+	return g.features[i].Name
+}
+
+func (g *graph) formatChain(chain []int) string {
+	names := make([]string, len(chain))
+	for i, c := range chain {
+		names[i] = g.name(c)
+	}
+	return strings.Join(names, " -> ")
+}
+
+// Enable enables the named features along with everything they require.
+// Names are qualified or unambiguous short names. Features are only updated
+// if all of them can be enabled. It returns the names of the features whose
+// status changed.
+func Enable(features []Feature, names ...string) ([]string, error) {
+	g := newGraph(features)
+	toEnable := make(map[int][]int)
+	for _, name := range names {
+		i, err := Find(features, name)
+		if err != nil {
+			return nil, err
+		}
+		if err := g.requirements(i, nil, toEnable); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range features {
+		chain, ok := toEnable[i]
+		if !ok {
+			continue
+		}
+		for j, f := range features {
+			_, enabling := toEnable[j]
+			if i == j || !(f.Enabled || enabling) || !g.conflicts(i, j) {
+				continue
+			}
+			return nil, fmt.Errorf("unable to enable %s: %s conflicts with %s",
+				g.formatChain(chain), g.name(i), g.name(j))
+		}
+	}
+
+	var changed []string
+	for i, f := range features {
+		if _, ok := toEnable[i]; ok && !f.Enabled {
+			features[i].Enabled = true
+			changed = append(changed, g.name(i))
+		}
+	}
+	return changed, nil
+}
+
+// Disable disables the named features. If an enabled feature still requires
+// one of them, Disable fails unless cascade is set, in which case those
+// dependent features are disabled too. Names are qualified or unambiguous
+// short names. Features are only updated if all of them can be disabled. It
+// returns the names of the features whose status changed.
+func Disable(features []Feature, cascade bool, names ...string) ([]string, error) {
+	g := newGraph(features)
+	toDisable := make(map[int]bool)
+	var requested []int
+	for _, name := range names {
+		i, err := Find(features, name)
+		if err != nil {
+			return nil, err
+		}
+		toDisable[i] = true
+		requested = append(requested, i)
+	}
+
+	for _, r := range requested {
+		dependents := make(map[int][]int)
+		g.dependents(r, nil, dependents)
+		for i := range features {
+			chain, ok := dependents[i]
+			if !ok || toDisable[i] {
+				continue
+			}
+			if !cascade {
+				return nil, fmt.Errorf("unable to disable %s: required by enabled feature %s (%s)",
+					g.name(r), g.name(i), g.formatChain(chain))
+			}
+			toDisable[i] = true
+		}
+	}
+
+	var changed []string
+	for i, f := range features {
+		if toDisable[i] && f.Enabled {
+			features[i].Enabled = false
+			changed = append(changed, g.name(i))
+		}
+	}
+	return changed, nil
+}
+
+// Validate checks that every enabled feature has its requirements enabled,
+// that no two enabled features conflict and that there are no dependency
+// cycles. All problems found are reported in the returned error.
+func Validate(features []Feature) error {
+	g := newGraph(features)
+	var problems []string
+	for i, f := range features {
+		if !f.Enabled {
+			continue
+		}
+		reached := make(map[int][]int)
+		if err := g.requirements(i, nil, reached); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		for j := range features {
+			chain, ok := reached[j]
+			if ok && !features[j].Enabled {
+				problems = append(problems, fmt.Sprintf("%s: required feature %s is disabled",
+					g.formatChain(chain), g.name(j)))
+			}
+		}
+		for j := i + 1; j < len(features); j++ {
+			if features[j].Enabled && g.conflicts(i, j) {
+				problems = append(problems, fmt.Sprintf("enabled features %s and %s conflict",
+					g.name(i), g.name(j)))
+			}
+		}
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("invalid feature selection:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsIndex(list []int, i int) bool {
+	for _, e := range list {
+		if e == i {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package feature
+
+import (
+	"strings"
+	"testing"
+)
+
+func dependencyFeatures() []Feature {
+	return []Feature{
+		{Name: "transformation", Enabled: false},
+		{Name: "aws_lambda", Enabled: false, Requires: []string{"transformation"}},
+		{Name: "google_functions", Enabled: true},
+		{Name: "nats", Enabled: false, Requires: []string{"aws_lambda"}, Conflicts: []string{"google_functions"}},
+	}
+}
+
+func enabledNames(features []Feature) []string {
+	var names []string
+	for _, f := range features {
+		if f.Enabled {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+func TestEnablePullsInRequirements(t *testing.T) {
+	features := dependencyFeatures()
+	changed, err := Enable(features, "aws_lambda")
+	if err != nil {
+		t.Fatal("unexpected error enabling aws_lambda", err)
+	}
+	if strings.Join(changed, ",") != "transformation,aws_lambda" {
+		t.Errorf("expected transformation and aws_lambda to change got %v", changed)
+	}
+	if err := Validate(features); err != nil {
+		t.Error("expected valid selection", err)
+	}
+}
+
+func TestEnableReportsConflict(t *testing.T) {
+	features := dependencyFeatures()
+	_, err := Enable(features, "nats")
+	if err == nil {
+		t.Fatal("expected conflict enabling nats")
+	}
+	if !strings.Contains(err.Error(), "nats conflicts with google_functions") {
+		t.Errorf("unexpected error %q", err)
+	}
+}
+
+func TestEnableReportsCycle(t *testing.T) {
+	features := []Feature{
+		{Name: "a", Requires: []string{"b"}},
+		{Name: "b", Requires: []string{"c"}},
+		{Name: "c", Requires: []string{"a"}},
+	}
+	_, err := Enable(features, "a")
+	if err == nil {
+		t.Fatal("expected cycle to be reported")
+	}
+	if !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("expected the cycle chain in the error got %q", err)
+	}
+}
+
+func TestEnableReportsMissingRequirement(t *testing.T) {
+	features := []Feature{{Name: "a", Requires: []string{"missing"}}}
+	_, err := Enable(features, "a")
+	if err == nil || !strings.Contains(err.Error(), "a -> missing") {
+		t.Errorf("expected missing requirement to be reported got %v", err)
+	}
+}
+
+func TestDisable(t *testing.T) {
+	features := dependencyFeatures()
+	if _, err := Enable(features, "aws_lambda"); err != nil {
+		t.Fatal("unexpected error enabling aws_lambda", err)
+	}
+
+	_, err := Disable(features, false, "transformation")
+	if err == nil {
+		t.Fatal("expected disabling a required feature to fail")
+	}
+	if !strings.Contains(err.Error(), "aws_lambda -> transformation") {
+		t.Errorf("expected the dependency chain in the error got %q", err)
+	}
+	if !features[0].Enabled {
+		t.Error("expected transformation to stay enabled")
+	}
+
+	changed, err := Disable(features, true, "transformation")
+	if err != nil {
+		t.Fatal("unexpected error cascading disable", err)
+	}
+	if len(changed) != 2 {
+		t.Errorf("expected 2 features to be disabled got %v", changed)
+	}
+	if names := enabledNames(features); len(names) != 1 || names[0] != "google_functions" {
+		t.Errorf("expected only google_functions to be enabled got %v", names)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	features := dependencyFeatures()
+	features[1].Enabled = true
+	features[3].Enabled = true
+	err := Validate(features)
+	if err == nil {
+		t.Fatal("expected invalid selection")
+	}
+	msg := err.Error()
+	for _, expected := range []string{
+		"aws_lambda -> transformation: required feature transformation is disabled",
+		"nats -> aws_lambda -> transformation",
+		"enabled features google_functions and nats conflict",
+	} {
+		if !strings.Contains(msg, expected) {
+			t.Errorf("expected %q in %q", expected, msg)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package feature
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// glooPluginsPackage is the package Gloo plugins register themselves with
+	glooPluginsPackage = "github.com/solo-io/gloo/pkg/plugins"
+)
+
+var (
+	filterLibPattern = regexp.MustCompile(`name\s*=\s*"filter_lib"`)
+	buildFiles       = []string{"BUILD", "BUILD.bazel"}
+)
+
+// Discover scans a repository for Gloo plugins and Envoy filters and
+// proposes a manifest for them. Gloo plugins are Go packages that register
+// a plugin with the Gloo plugin registry; Envoy filters are Bazel packages
+// with a filter_lib target. An Envoy filter in the same directory as a Gloo
+// plugin or in its envoy subdirectory belongs to the same feature.
+func Discover(root string) ([]ManifestFeature, error) {
+	var glooDirs, envoyDirs []string
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if p != root && skipDir(info.Name()) {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if ok, err := isGlooPlugin(p); err != nil {
+			return err
+		} else if ok {
+			glooDirs = append(glooDirs, rel)
+		}
+		if isEnvoyFilter(p) {
+			envoyDirs = append(envoyDirs, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var mf []ManifestFeature
+	paired := make(map[string]bool)
+	for _, g := range glooDirs {
+		f := ManifestFeature{GlooDir: g}
+		for _, e := range envoyDirs {
+			if !paired[e] && (e == g || e == joinDir(g, "envoy")) {
+				f.EnvoyDir = e
+				paired[e] = true
+				break
+			}
+		}
+		mf = append(mf, f)
+	}
+	for _, e := range envoyDirs {
+		if !paired[e] {
+			mf = append(mf, ManifestFeature{EnvoyDir: e})
+		}
+	}
+	nameFeatures(root, mf)
+	sort.Slice(mf, func(i, j int) bool { return mf[i].Name < mf[j].Name })
+	return mf, nil
+}
+
+// SaveManifest writes the features manifest to a file
+func SaveManifest(filename string, mf []ManifestFeature) error {
+	b, err := json.MarshalIndent(mf, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(b, '\n'), 0644)
+}
+
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+		name == "vendor" || name == "testdata" || name == "node_modules"
+}
+
+func joinDir(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// isGlooPlugin checks if any Go file in the directory calls Register from
+// the Gloo plugins package
+func isGlooPlugin(dir string) (bool, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+	for _, fi := range files {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".go") || strings.HasSuffix(fi.Name(), "_test.go") {
+			continue
+		}
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, filepath.Join(dir, fi.Name()), nil, 0)
+		if err != nil {
+			// not our problem if the repository has broken Go files
+			continue
+		}
+		if registersPlugin(f) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func registersPlugin(f *ast.File) bool {
+	local := ""
+	for _, imp := range f.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || !strings.HasSuffix(p, glooPluginsPackage) {
+			continue
+		}
+		local = "plugins"
+		if imp.Name != nil {
+			local = imp.Name.Name
+		}
+	}
+	if local == "" {
+		return false
+	}
+	found := false
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return !found
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Register" {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Name == local {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// isEnvoyFilter checks if the directory is a Bazel package with a
+// filter_lib target
+func isEnvoyFilter(dir string) bool {
+	for _, name := range buildFiles {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err == nil && filterLibPattern.Match(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// nameFeatures names each feature after its directory. Envoy only features
+// in an envoy directory are named after the parent directory. Features with
+// the same name are named after their full path instead.
+func nameFeatures(root string, mf []ManifestFeature) {
+	dirs := make([]string, len(mf))
+	count := make(map[string]int)
+	for i, f := range mf {
+		dir := f.GlooDir
+		if dir == "" {
+			dir = f.EnvoyDir
+			if path.Base(dir) == "envoy" {
+				dir = path.Dir(dir)
+			}
+		}
+		if dir == "." {
+			if abs, err := filepath.Abs(root); err == nil {
+				dir = filepath.Base(abs)
+			}
+		}
+		dirs[i] = dir
+		count[featureName(path.Base(dir))]++
+	}
+	for i, dir := range dirs {
+		name := featureName(path.Base(dir))
+		if count[name] > 1 {
+			name = featureName(dir)
+		}
+		mf[i].Name = name
+	}
+}
+
+// featureName turns a path into a feature name
+func featureName(p string) string {
+	return strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(p)
+}
@@ -4,7 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/solo-io/thetool/pkg/workspace"
 )
 
 const (
@@ -12,15 +18,35 @@ const (
 	FeaturesFileName = "features.json"
 )
 
+// Metadata describes a feature for the people choosing features
+type Metadata struct {
+	Description string   `json:"description,omitempty"`
+	Maintainers []string `json:"maintainers,omitempty"`
+	License     string   `json:"license,omitempty"`
+	Homepage    string   `json:"homepage,omitempty"`
+	Docs        string   `json:"docs,omitempty"`
+}
+
 type ManifestFeature struct {
-	Name     string   `json:"name"`
-	GlooDir  string   `json:"gloo,omitempty"`
-	EnvoyDir string   `json:"envoy,omitempty"`
-	Enabled  *bool    `json:"enabled,omitempty"`
-	Tags     []string `json:"tags,omitempty"`
+	Metadata
+	Name      string   `json:"name"`
+	GlooDir   string   `json:"gloo,omitempty"`
+	EnvoyDir  string   `json:"envoy,omitempty"`
+	Enabled   *bool    `json:"enabled,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Requires  []string `json:"requires,omitempty"`
+	Conflicts []string `json:"conflicts,omitempty"`
+	// Include makes the entry pull in another manifest instead of being a
+	// feature
+	Include *Include `json:"include,omitempty"`
 }
 
+// LoadManifest reads the features manifest from the given file. If filename
+// is a directory, the default features file in that directory is used.
 func LoadManifest(filename string) ([]ManifestFeature, error) {
+	if fi, err := os.Stat(filename); err == nil && fi.IsDir() {
+		filename = filepath.Join(filename, FeaturesFileName)
+	}
 	b, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, err
@@ -33,34 +59,103 @@ func LoadManifest(filename string) ([]ManifestFeature, error) {
 	return mf, nil
 }
 
+// ToFeatures turns the features of a manifest into the features of the
+// repository at the revision. Includes are left to Compose.
 func ToFeatures(repo, hash string, mf []ManifestFeature) []Feature {
-	features := make([]Feature, len(mf))
-	for i, f := range mf {
+	features := []Feature{}
+	for _, f := range mf {
+		if f.Include != nil {
+			continue
+		}
 		enabled := true
 		if f.Enabled != nil {
 			enabled = *f.Enabled
 		}
-		features[i] = Feature{
+		features = append(features, Feature{
 			Name:       f.Name,
 			GlooDir:    f.GlooDir,
 			EnvoyDir:   f.EnvoyDir,
 			Repository: repo,
+			Alias:      DefaultAlias(repo),
 			Revision:   hash,
 			Enabled:    enabled,
 			Tags:       f.Tags,
-		}
+			Requires:   f.Requires,
+			Conflicts:  f.Conflicts,
+			Metadata:   f.Metadata,
+		})
 	}
 	return features
 }
 
+// Feature is a Gloo feature from a repository. Alias is the short name of
+// the repository used to qualify the feature name.
 type Feature struct {
 	Name       string   `json:"name"`
 	GlooDir    string   `json:"gloo,omitempty"`
 	EnvoyDir   string   `json:"envoy,omitempty"`
 	Repository string   `json:"repository"`
+	Alias      string   `json:"alias,omitempty"`
 	Revision   string   `json:"revision"`
+	Root       string   `json:"root,omitempty"`
 	Enabled    bool     `json:"enabled"`
 	Tags       []string `json:"tags,omitempty"`
+	Requires   []string `json:"requires,omitempty"`
+	Conflicts  []string `json:"conflicts,omitempty"`
+	// Provenance lists the manifests the feature was found through, from
+	// the manifest of its repository to the one defining it
+	Provenance string `json:"provenance,omitempty"`
+	Metadata
+}
+
+// ID is the qualified name of the feature, unique across repositories
+func (f Feature) ID() string {
+	alias := f.Alias
+	if alias == "" {
+		alias = DefaultAlias(f.Repository)
+	}
+	return alias + "/" + f.Name
+}
+
+// DefaultAlias is the alias used for a repository unless another one is
+// given; it is the name of the repository without any extension
+func DefaultAlias(repoURL string) string {
+	name := path.Base(strings.TrimSuffix(repoURL, "/"))
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// Find returns the index of the feature with the given qualified name
+// (alias/name) or unambiguous short name
+func Find(features []Feature, name string) (int, error) {
+	var matches []int
+	for i, f := range features {
+		if f.ID() == name {
+			return i, nil
+		}
+		if f.Name == name {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return -1, fmt.Errorf("unable to find feature %s", name)
+	case 1:
+		return matches[0], nil
+	default:
+		ids := make([]string, len(matches))
+		for i, m := range matches {
+			ids[i] = features[m].ID()
+		}
+		return -1, fmt.Errorf("feature name %s is ambiguous; use one of %s", name, strings.Join(ids, ", "))
+	}
+}
+
+// sameFeature checks if both features are the same feature from the same repository
+func sameFeature(a, b Feature) bool {
+	return a.Name == b.Name && a.Repository == b.Repository
 }
 
 type FeatureStore interface {
@@ -87,13 +182,13 @@ func (f *FileFeatureStore) AddOrUpdateAll(features []Feature) error {
 		return err
 	}
 
-	// remove any existing features for this repo
-	filtered := removeRepo(features[0].Repository, existing)
-	var updated []Feature
+	// replace any existing features for this repo; features with the same
+	// name from other repositories are told apart by their alias
+	updated := removeRepo(features[0].Repository, existing)
 	for _, feature := range features {
-		for _, e := range filtered {
-			if e.Name == feature.Name {
-				return fmt.Errorf("feature %s already exists from another repository %s", feature.Name, e.Repository)
+		for _, e := range updated {
+			if e.ID() == feature.ID() {
+				return fmt.Errorf("feature %s already exists from another repository %s", feature.ID(), e.Repository)
 			}
 		}
 		updated = append(updated, feature)
@@ -102,6 +197,12 @@ func (f *FileFeatureStore) AddOrUpdateAll(features []Feature) error {
 }
 
 func (f *FileFeatureStore) Update(feature Feature) error {
+	return f.UpdateAll([]Feature{feature})
+}
+
+// UpdateAll replaces the stored features with the given ones, matched by
+// name and repository, in a single write
+func (f *FileFeatureStore) UpdateAll(features []Feature) error {
 	existing, err := f.List()
 	if err != nil {
 		return err
@@ -109,10 +210,12 @@ func (f *FileFeatureStore) Update(feature Feature) error {
 
 	updated := make([]Feature, len(existing))
 	for i, e := range existing {
-		if e.Name == feature.Name {
-			updated[i] = feature
-		} else {
-			updated[i] = e
+		updated[i] = e
+		for _, feature := range features {
+			if sameFeature(e, feature) {
+				updated[i] = feature
+				break
+			}
 		}
 	}
 	return f.save(updated)
@@ -140,19 +243,20 @@ func removeRepo(repo string, features []Feature) []Feature {
 
 func (f *FileFeatureStore) save(features []Feature) error {
 	b, err := json.MarshalIndent(featureFile{
-		Date:        time.Now(),
-		GeneratedBy: "thetool",
-		Features:    features,
+		SchemaVersion: featureSchema.Version,
+		Date:          time.Now(),
+		GeneratedBy:   "thetool",
+		Features:      features,
 	}, "", " ")
 	if err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(f.Filename, b, 0644)
+	return workspace.WriteFile(f.Filename, b, 0644)
 }
 
 func (f *FileFeatureStore) List() ([]Feature, error) {
-	b, err := ioutil.ReadFile(f.Filename)
+	b, err := featureSchema.ReadFile(f.Filename)
 	if err != nil {
 		return nil, err
 	}
@@ -165,7 +269,11 @@ func (f *FileFeatureStore) List() ([]Feature, error) {
 }
 
 type featureFile struct {
-	Date        time.Time `json:"date"`
-	GeneratedBy string    `json:"generatedBy"`
-	Features    []Feature `json:"features"`
+	SchemaVersion int       `json:"schemaVersion"`
+	Date          time.Time `json:"date"`
+	GeneratedBy   string    `json:"generatedBy"`
+	Features      []Feature `json:"features"`
 }
+
+// featureSchema versions the features file
+var featureSchema = workspace.Schema{Version: 1}
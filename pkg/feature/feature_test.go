@@ -1,6 +1,9 @@
 package feature
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestManifest(t *testing.T) {
 	mf, err := LoadManifest("testdata")
@@ -23,4 +26,94 @@ func TestManifest(t *testing.T) {
 	if len(features[1].Tags) != 1 {
 		t.Error("expected there to be a tag")
 	}
+
+	if features[0].Description == "" || features[0].License != "Apache-2.0" || len(features[0].Maintainers) != 1 {
+		t.Errorf("expected metadata from the manifest got %+v", features[0].Metadata)
+	}
+}
+
+func TestFind(t *testing.T) {
+	features := []Feature{
+		{Name: "aws_lambda", Repository: "https://github.com/solo-io/gloo.git"},
+		{Name: "aws_lambda", Repository: "https://github.com/axhixh/plugins.git", Alias: "fork"},
+		{Name: "nats", Repository: "https://github.com/solo-io/gloo.git"},
+	}
+	cases := []struct {
+		name  string
+		index int
+	}{
+		{"gloo/aws_lambda", 0},
+		{"fork/aws_lambda", 1},
+		{"nats", 2},
+		{"gloo/nats", 2},
+	}
+	for _, c := range cases {
+		i, err := Find(features, c.name)
+		if err != nil || i != c.index {
+			t.Errorf("expected %s to be feature %d got %d %v", c.name, c.index, i, err)
+		}
+	}
+	if _, err := Find(features, "aws_lambda"); err == nil {
+		t.Error("expected ambiguous name to fail")
+	}
+	if _, err := Find(features, "fork/nats"); err == nil {
+		t.Error("expected unknown qualified name to fail")
+	}
+}
+
+func TestRequiresPreferSameRepository(t *testing.T) {
+	features := []Feature{
+		{Name: "transformation", Repository: "upstream.git"},
+		{Name: "transformation", Repository: "fork.git"},
+		{Name: "aws_lambda", Repository: "fork.git", Requires: []string{"transformation"}},
+	}
+	changed, err := Enable(features, "aws_lambda")
+	if err != nil {
+		t.Fatal("unexpected error enabling aws_lambda", err)
+	}
+	if features[0].Enabled || !features[1].Enabled {
+		t.Errorf("expected transformation from the same repository to be enabled got %v", changed)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	mf, err := Discover("testdata/discover")
+	if err != nil {
+		t.Fatal("unable to discover features", err)
+	}
+	expected := []ManifestFeature{
+		{Name: "aws", GlooDir: "aws", EnvoyDir: "aws/envoy"},
+		{Name: "nats", EnvoyDir: "nats/envoy"},
+	}
+	if !reflect.DeepEqual(mf, expected) {
+		t.Errorf("expected %+v got %+v", expected, mf)
+	}
+}
+
+func TestSubdir(t *testing.T) {
+	r := Repository{URL: "https://example.com/mono.git", Subdir: "thetool"}
+	if m := r.ManifestFile(); m != "thetool/features.json" {
+		t.Errorf("expected the default manifest in the subdirectory got %s", m)
+	}
+	r.Manifest = "plugins/features.json"
+	if m := r.ManifestFile(); m != "thetool/plugins/features.json" {
+		t.Errorf("expected the manifest relative to the subdirectory got %s", m)
+	}
+
+	features := ToFeatures(r.URL, "aa23", []ManifestFeature{{Name: "aws", GlooDir: "aws", EnvoyDir: "aws/envoy"}, {Name: "nats", EnvoyDir: "nats"}})
+	InSubdir(features, r.Subdir)
+	if features[0].GlooDir != "thetool/aws" || features[0].EnvoyDir != "thetool/aws/envoy" || features[1].GlooDir != "" {
+		t.Errorf("expected feature directories in the subdirectory got %+v", features)
+	}
+
+	for subdir, expected := range map[string]string{"": "", ".": "", "./thetool/": "thetool", "a/../b": "b"} {
+		if clean, err := CleanSubdir(subdir); err != nil || clean != expected {
+			t.Errorf("expected %q for %q got %q, %v", expected, subdir, clean, err)
+		}
+	}
+	for _, subdir := range []string{"..", "../other", "/etc"} {
+		if _, err := CleanSubdir(subdir); err == nil {
+			t.Errorf("expected subdirectory %s to be refused", subdir)
+		}
+	}
 }
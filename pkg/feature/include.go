@@ -0,0 +1,181 @@
+package feature
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Include is an entry of a manifest that pulls in the features of another
+// manifest, either in the same repository or at a commit of another one.
+// In a manifest it is written as {"include": "plugins/features.json"} or
+// {"include": {"repository": "...", "commit": "...", "manifest": "..."}}.
+type Include struct {
+	// Manifest is the path of the included manifest. In the same repository
+	// it is relative to the directory of the including manifest; in another
+	// one it is relative to its root and defaults to the features file.
+	Manifest string `json:"manifest,omitempty"`
+	// Repository is the URL of another repository
+	Repository string `json:"repository,omitempty"`
+	// Commit is the branch, tag or commit of the other repository
+	Commit string `json:"commit,omitempty"`
+	// Alias qualifies the features of the other repository
+	Alias  string `json:"alias,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// UnmarshalJSON accepts the path of a manifest in the same repository as a
+// plain string
+func (i *Include) UnmarshalJSON(b []byte) error {
+	var manifest string
+	if err := json.Unmarshal(b, &manifest); err == nil {
+		*i = Include{Manifest: manifest}
+		return nil
+	}
+	type include Include
+	return json.Unmarshal(b, (*include)(i))
+}
+
+func (i Include) String() string {
+	if i.Repository == "" {
+		return i.Manifest
+	}
+	manifest := i.Manifest
+	if manifest == "" {
+		manifest = FeaturesFileName
+	}
+	return fmt.Sprintf("%s@%s:%s", i.Repository, i.Commit, manifest)
+}
+
+// Resolver downloads the repository an include refers to. The repository
+// it returns has its alias, root and commit set, and the commit of the
+// include as its ref.
+type Resolver func(Include) (Repository, error)
+
+// Composition is what the manifest of a repository adds up to with the
+// manifests it includes
+type Composition struct {
+	Features []Feature
+	// Included are the other repositories the manifests include
+	Included []Repository
+	// Overridden lists the features dropped because a manifest that takes
+	// precedence defines them too
+	Overridden []string
+}
+
+// Compose loads the manifest of the downloaded repository and the manifests
+// it includes. A manifest takes precedence over the manifests it includes,
+// and an include over the ones after it: the first definition of a feature
+// of a repository is kept. Every feature records the manifests it was found
+// through in its provenance.
+func Compose(r Repository, resolve Resolver) (*Composition, error) {
+	c := &composer{
+		resolve: resolve,
+		top:     r,
+		defined: make(map[string]string),
+		repos:   make(map[string]Repository),
+	}
+	if err := c.visit(r, r.ManifestFile(), nil); err != nil {
+		return nil, err
+	}
+	return &c.Composition, nil
+}
+
+type composer struct {
+	Composition
+	resolve Resolver
+	top     Repository
+	// defined maps features to the provenance of their definition
+	defined map[string]string
+	repos   map[string]Repository
+}
+
+func (c *composer) visit(r Repository, manifest string, trail []string) error {
+	label := r.ID() + ":" + manifest
+	for _, t := range trail {
+		if t == label {
+			return fmt.Errorf("manifest %s includes itself through %s", label, strings.Join(append(trail, label), " > "))
+		}
+	}
+	trail = append(append([]string{}, trail...), label)
+	provenance := strings.Join(trail, " > ")
+
+	mf, err := LoadManifest(filepath.Join(r.Root, filepath.FromSlash(manifest)))
+	if err != nil {
+		if len(trail) == 1 {
+			return err
+		}
+		return errors.Wrapf(err, "unable to load manifest %s", provenance)
+	}
+	features := ToFeatures(r.URL, r.Commit, mf)
+	InSubdir(features, r.Subdir)
+	for _, f := range features {
+		f.Alias, f.Root, f.Provenance = r.ID(), r.Root, provenance
+		key := f.Repository + "\x00" + f.Name
+		if first, ok := c.defined[key]; ok {
+			c.Overridden = append(c.Overridden, fmt.Sprintf("%s from %s is overridden by the one from %s", f.ID(), provenance, first))
+			continue
+		}
+		c.defined[key] = provenance
+		c.Features = append(c.Features, f)
+	}
+
+	for _, e := range mf {
+		if e.Include == nil {
+			continue
+		}
+		inc := *e.Include
+		if inc.Repository == "" {
+			if inc.Manifest == "" {
+				return fmt.Errorf("include without a manifest or repository in %s", provenance)
+			}
+			included, err := CleanSubdir(path.Join(path.Dir(manifest), filepath.ToSlash(inc.Manifest)))
+			if err != nil || included == "" {
+				return fmt.Errorf("manifest %s included by %s is outside of the repository", inc.Manifest, provenance)
+			}
+			if err := c.visit(r, included, trail); err != nil {
+				return err
+			}
+			continue
+		}
+		other, err := c.repository(inc, provenance)
+		if err != nil {
+			return err
+		}
+		included := FeaturesFileName
+		if inc.Manifest != "" {
+			if included, err = CleanSubdir(inc.Manifest); err != nil || included == "" {
+				return fmt.Errorf("manifest %s included by %s is outside of the repository", inc.Manifest, provenance)
+			}
+		}
+		if err := c.visit(other, included, trail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// repository resolves the repository of the include once; all the includes
+// of a repository must use the same commit
+func (c *composer) repository(inc Include, provenance string) (Repository, error) {
+	if inc.Repository == c.top.URL {
+		return Repository{}, fmt.Errorf("%s includes a manifest of its own repository by URL; use a relative path", provenance)
+	}
+	if r, ok := c.repos[inc.Repository]; ok {
+		if r.Ref != inc.Commit {
+			return r, fmt.Errorf("repository %s is included at %s and at %s", inc.Repository, r.Ref, inc.Commit)
+		}
+		return r, nil
+	}
+	r, err := c.resolve(inc)
+	if err != nil {
+		return r, errors.Wrapf(err, "unable to include %s in %s", inc, provenance)
+	}
+	c.repos[inc.Repository] = r
+	c.Included = append(c.Included, r)
+	return r, nil
+}
@@ -0,0 +1,53 @@
+package feature
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	catalog := Repository{URL: "https://example.com/catalog.git", Commit: "c1", Root: "testdata/include"}
+	resolved := 0
+	resolve := func(inc Include) (Repository, error) {
+		resolved++
+		return Repository{URL: inc.Repository, Alias: inc.Alias, Ref: inc.Commit, Commit: "o1", Root: "testdata/include/other", IncludedBy: catalog.URL}, nil
+	}
+	c, err := Compose(catalog, resolve)
+	if err != nil {
+		t.Fatal("unable to compose manifests", err)
+	}
+	if resolved != 1 || len(c.Included) != 1 {
+		t.Errorf("expected the other repository to be included once got %d", resolved)
+	}
+
+	expected := map[string]string{
+		"catalog/aws":  "catalog:features.json",
+		"catalog/nats": "catalog:features.json > catalog:plugins/features.json",
+		"other/aws":    "catalog:features.json > other:features.json",
+	}
+	if len(c.Features) != len(expected) {
+		t.Fatalf("expected %d features got %+v", len(expected), c.Features)
+	}
+	for _, f := range c.Features {
+		if expected[f.ID()] != f.Provenance {
+			t.Errorf("expected %s from %q got %q", f.ID(), expected[f.ID()], f.Provenance)
+		}
+		if f.ID() == "catalog/aws" && f.Description != "the catalog's own aws" {
+			t.Error("expected the including manifest to take precedence")
+		}
+		if f.ID() == "other/aws" && (f.Revision != "o1" || f.Root != "testdata/include/other") {
+			t.Errorf("expected the included feature at the included commit got %+v", f)
+		}
+	}
+	if len(c.Overridden) != 1 || !strings.Contains(c.Overridden[0], "catalog:plugins/features.json") {
+		t.Errorf("expected the included aws to be overridden got %v", c.Overridden)
+	}
+}
+
+func TestComposeLoop(t *testing.T) {
+	r := Repository{URL: "https://example.com/loop.git", Root: "testdata/include", Manifest: "loop/features.json"}
+	_, err := Compose(r, nil)
+	if err == nil || !strings.Contains(err.Error(), "includes itself") {
+		t.Errorf("expected the include loop to be reported got %v", err)
+	}
+}
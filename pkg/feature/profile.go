@@ -0,0 +1,199 @@
+package feature
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/solo-io/thetool/pkg/workspace"
+)
+
+const (
+	// ProfilesFileName represents the filename for named feature profiles
+	ProfilesFileName = "profiles.json"
+)
+
+// Profile is a named set of enabled features
+type Profile struct {
+	Name     string   `json:"name"`
+	Features []string `json:"features"`
+}
+
+// NewProfile creates a profile from the features currently enabled
+func NewProfile(name string, features []Feature) Profile {
+	p := Profile{Name: name, Features: []string{}}
+	for _, f := range features {
+		if f.Enabled {
+			p.Features = append(p.Features, f.ID())
+		}
+	}
+	sort.Strings(p.Features)
+	return p
+}
+
+// resolve finds the index of every feature in the profile
+func (p Profile) resolve(features []Feature) (map[int]bool, error) {
+	selected := make(map[int]bool)
+	for _, name := range p.Features {
+		i, err := Find(features, name)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %v", p.Name, err)
+		}
+		selected[i] = true
+	}
+	return selected, nil
+}
+
+// Matches checks if the profile enables exactly the enabled features
+func (p Profile) Matches(features []Feature) bool {
+	selected, err := p.resolve(features)
+	if err != nil {
+		return false
+	}
+	for i, f := range features {
+		if f.Enabled != selected[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply enables the features in the profile and disables all the others.
+// The features are only updated if every feature in the profile exists and
+// the resulting selection is valid. It returns the names of the features
+// whose status changed.
+func (p Profile) Apply(features []Feature) ([]string, error) {
+	selected, err := p.resolve(features)
+	if err != nil {
+		return nil, err
+	}
+	g := newGraph(features)
+	updated := make([]Feature, len(features))
+	copy(updated, features)
+	var changed []string
+	for i, f := range updated {
+		if f.Enabled != selected[i] {
+			updated[i].Enabled = selected[i]
+			changed = append(changed, g.name(i))
+		}
+	}
+	if err := Validate(updated); err != nil {
+		return nil, fmt.Errorf("unable to use profile %s: %v", p.Name, err)
+	}
+	copy(features, updated)
+	return changed, nil
+}
+
+// SelectByTag returns the qualified names of features with any of the given
+// tags. Every tag needs to match at least one feature.
+func SelectByTag(features []Feature, tags ...string) ([]string, error) {
+	var names []string
+	for _, tag := range tags {
+		found := false
+		for _, f := range features {
+			if contains(f.Tags, tag) {
+				found = true
+				if !contains(names, f.ID()) {
+					names = append(names, f.ID())
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unable to find features with tag %s", tag)
+		}
+	}
+	return names, nil
+}
+
+type FileProfileStore struct {
+	Filename string
+}
+
+// Save adds the profile or replaces an existing profile with the same name
+func (p *FileProfileStore) Save(profile Profile) error {
+	existing, err := p.List()
+	if err != nil {
+		return err
+	}
+	updated := []Profile{}
+	for _, e := range existing {
+		if e.Name != profile.Name {
+			updated = append(updated, e)
+		}
+	}
+	updated = append(updated, profile)
+	sort.Slice(updated, func(i, j int) bool { return updated[i].Name < updated[j].Name })
+	return p.save(updated)
+}
+
+func (p *FileProfileStore) Get(name string) (Profile, error) {
+	existing, err := p.List()
+	if err != nil {
+		return Profile{}, err
+	}
+	for _, e := range existing {
+		if e.Name == name {
+			return e, nil
+		}
+	}
+	return Profile{}, fmt.Errorf("unable to find profile %s", name)
+}
+
+func (p *FileProfileStore) Remove(name string) error {
+	existing, err := p.List()
+	if err != nil {
+		return err
+	}
+	updated := []Profile{}
+	for _, e := range existing {
+		if e.Name != name {
+			updated = append(updated, e)
+		}
+	}
+	if len(updated) == len(existing) {
+		return fmt.Errorf("unable to find profile %s", name)
+	}
+	return p.save(updated)
+}
+
+// List returns the saved profiles; it is empty if no profile was saved yet
+func (p *FileProfileStore) List() ([]Profile, error) {
+	b, err := profileSchema.ReadFile(p.Filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Profile{}, nil
+		}
+		return nil, err
+	}
+	pf := &profileFile{}
+	err = json.Unmarshal(b, pf)
+	if err != nil {
+		return nil, err
+	}
+	return pf.Profiles, nil
+}
+
+func (p *FileProfileStore) save(profiles []Profile) error {
+	b, err := json.MarshalIndent(profileFile{
+		SchemaVersion: profileSchema.Version,
+		Date:          time.Now(),
+		GeneratedBy:   "thetool",
+		Profiles:      profiles,
+	}, "", " ")
+	if err != nil {
+		return err
+	}
+	return workspace.WriteFile(p.Filename, b, 0644)
+}
+
+type profileFile struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	Date          time.Time `json:"date"`
+	GeneratedBy   string    `json:"generatedBy"`
+	Profiles      []Profile `json:"profiles"`
+}
+
+// profileSchema versions the profiles file
+var profileSchema = workspace.Schema{Version: 1}
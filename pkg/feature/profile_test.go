@@ -0,0 +1,87 @@
+package feature
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSelectByTag(t *testing.T) {
+	features := []Feature{
+		{Name: "aws_lambda", Tags: []string{"aws", "functions"}},
+		{Name: "google_functions", Tags: []string{"functions"}},
+		{Name: "kubernetes"},
+	}
+	names, err := SelectByTag(features, "functions")
+	if err != nil {
+		t.Fatal("unexpected error selecting by tag", err)
+	}
+	if len(names) != 2 {
+		t.Errorf("expected 2 features got %v", names)
+	}
+
+	if _, err := SelectByTag(features, "aws", "unknown"); err == nil {
+		t.Error("expected unknown tag to fail")
+	}
+}
+
+func TestProfileApply(t *testing.T) {
+	features := dependencyFeatures()
+	p := Profile{Name: "edge", Features: []string{"transformation", "aws_lambda"}}
+	changed, err := p.Apply(features)
+	if err != nil {
+		t.Fatal("unexpected error applying profile", err)
+	}
+	if len(changed) != 3 {
+		t.Errorf("expected 3 features to change got %v", changed)
+	}
+	if !p.Matches(features) {
+		t.Error("expected profile to match after applying it")
+	}
+
+	invalid := Profile{Name: "broken", Features: []string{"aws_lambda"}}
+	if _, err := invalid.Apply(features); err == nil {
+		t.Error("expected profile with missing requirement to fail")
+	}
+	unknown := Profile{Name: "unknown", Features: []string{"nope"}}
+	if _, err := unknown.Apply(features); err == nil {
+		t.Error("expected profile with unknown feature to fail")
+	}
+	if !p.Matches(features) {
+		t.Error("expected failed profiles to leave features unchanged")
+	}
+}
+
+func TestFileProfileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thetool-test")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := &FileProfileStore{Filename: filepath.Join(dir, ProfilesFileName)}
+	profiles, err := store.List()
+	if err != nil || len(profiles) != 0 {
+		t.Fatalf("expected no profiles got %v %v", profiles, err)
+	}
+	if err := store.Save(NewProfile("full", dependencyFeatures())); err != nil {
+		t.Fatal("unable to save profile", err)
+	}
+	if err := store.Save(Profile{Name: "full", Features: []string{"aws_lambda"}}); err != nil {
+		t.Fatal("unable to replace profile", err)
+	}
+	p, err := store.Get("full")
+	if err != nil {
+		t.Fatal("unable to get profile", err)
+	}
+	if len(p.Features) != 1 {
+		t.Errorf("expected replaced profile got %v", p)
+	}
+	if err := store.Remove("full"); err != nil {
+		t.Error("unable to remove profile", err)
+	}
+	if _, err := store.Get("full"); err == nil {
+		t.Error("expected removed profile to be gone")
+	}
+}
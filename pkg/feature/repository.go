@@ -3,8 +3,12 @@ package feature
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/solo-io/thetool/pkg/workspace"
 )
 
 const (
@@ -13,9 +17,79 @@ const (
 )
 
 type Repository struct {
-	URL      string `json:"url"`
-	Commit   string `json:"commit"`
+	URL string `json:"url"`
+	// Alias is the short name used to qualify the features of the repository
+	Alias string `json:"alias,omitempty"`
+	// Ref is the branch, tag or commit the repository was added with
+	Ref string `json:"ref,omitempty"`
+	// Commit is the commit Ref resolved to
+	Commit string `json:"commit"`
+	// Manifest is the path of the features manifest, relative to Subdir;
+	// it is the default features file if empty
 	Manifest string `json:"manifest"`
+	// Subdir is the directory of the repository with its features, for
+	// repositories that hold more than features. The manifest and the
+	// directories of the features are relative to it.
+	Subdir string `json:"subdir,omitempty"`
+	// Discover is set for repositories without a manifest; their features
+	// are found by scanning the repository
+	Discover bool `json:"discover,omitempty"`
+	// Root is the directory with the content of the repository as reported
+	// by the fetcher that downloaded it
+	Root string `json:"root,omitempty"`
+	// SHA256 is the checksum of the archive of repositories downloaded as
+	// a single file. It is verified whenever the commit is downloaded again.
+	SHA256 string `json:"sha256,omitempty"`
+	// IncludedBy is the URL of the repository whose manifest includes this
+	// one. Included repositories are updated and removed with it.
+	IncludedBy string `json:"includedBy,omitempty"`
+}
+
+// ID is the alias of the repository, or the default alias for repositories
+// added without one
+func (r Repository) ID() string {
+	if r.Alias != "" {
+		return r.Alias
+	}
+	return DefaultAlias(r.URL)
+}
+
+// ManifestFile is the path of the features manifest in the repository
+func (r Repository) ManifestFile() string {
+	manifest := r.Manifest
+	if manifest == "" {
+		manifest = FeaturesFileName
+	}
+	return path.Join(r.Subdir, manifest)
+}
+
+// CleanSubdir cleans the subdirectory of a repository and makes sure it
+// stays within the repository; the repository itself is empty
+func CleanSubdir(subdir string) (string, error) {
+	clean := path.Clean(filepath.ToSlash(subdir))
+	if clean == "." {
+		return "", nil
+	}
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || filepath.VolumeName(subdir) != "" {
+		return "", fmt.Errorf("subdirectory %s is outside of the repository", subdir)
+	}
+	return clean, nil
+}
+
+// InSubdir makes the directories of the features, which are relative to the
+// subdirectory of their repository, relative to the repository itself
+func InSubdir(features []Feature, subdir string) {
+	if subdir == "" {
+		return
+	}
+	for i, f := range features {
+		if f.GlooDir != "" {
+			features[i].GlooDir = path.Join(subdir, f.GlooDir)
+		}
+		if f.EnvoyDir != "" {
+			features[i].EnvoyDir = path.Join(subdir, f.EnvoyDir)
+		}
+	}
 }
 
 type RepositoryStore interface {
@@ -42,7 +116,7 @@ func (r *FileRepoStore) AddOrUpdate(repo Repository) (bool, error) {
 	for i, r := range repos {
 		if r.URL == repo.URL {
 			alreadyExists = true
-			repos[i].Commit = repo.Commit
+			repos[i] = repo
 			break
 		}
 	}
@@ -74,18 +148,19 @@ func (r *FileRepoStore) Remove(repoURL string) error {
 
 func (r *FileRepoStore) save(repos []Repository) error {
 	b, err := json.MarshalIndent(repoFile{
-		Date:        time.Now(),
-		GeneratedBy: "thetool",
-		Repos:       repos,
+		SchemaVersion: repoSchema.Version,
+		Date:          time.Now(),
+		GeneratedBy:   "thetool",
+		Repos:         repos,
 	}, "", " ")
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(r.Filename, b, 0644)
+	return workspace.WriteFile(r.Filename, b, 0644)
 }
 
 func (r *FileRepoStore) List() ([]Repository, error) {
-	b, err := ioutil.ReadFile(r.Filename)
+	b, err := repoSchema.ReadFile(r.Filename)
 	if err != nil {
 		return nil, err
 	}
@@ -98,7 +173,11 @@ func (r *FileRepoStore) List() ([]Repository, error) {
 }
 
 type repoFile struct {
-	Date        time.Time    `json:"date"`
-	GeneratedBy string       `json:"generatedBy"`
-	Repos       []Repository `json:"repositories"`
+	SchemaVersion int          `json:"schemaVersion"`
+	Date          time.Time    `json:"date"`
+	GeneratedBy   string       `json:"generatedBy"`
+	Repos         []Repository `json:"repositories"`
 }
+
+// repoSchema versions the repositories file
+var repoSchema = workspace.Schema{Version: 1}
@@ -0,0 +1,11 @@
+package aws
+
+import (
+	"github.com/solo-io/gloo/pkg/plugins"
+)
+
+func init() {
+	plugins.Register(&Plugin{})
+}
+
+type Plugin struct{}
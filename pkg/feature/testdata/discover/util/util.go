@@ -0,0 +1,5 @@
+package util
+
+import "github.com/solo-io/gloo/pkg/plugins"
+
+var _ plugins.Plugin
@@ -0,0 +1,33 @@
+// Package filelock locks files between processes. The operating system
+// releases the lock when the process ends, so a crashed or killed thetool
+// never leaves a file locked.
+package filelock
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrLocked is returned when the file is locked and the lock isn't waited for
+var ErrLocked = errors.New("locked")
+
+// Lock opens or creates the file and locks it exclusively. If another
+// process or another open file of this process holds the lock, it waits for
+// it or, without wait, fails with ErrLocked.
+func Lock(filename string, wait bool) (*os.File, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lock(f, wait); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Unlock unlocks and closes the file
+func Unlock(f *os.File) error {
+	defer f.Close()
+	return unlock(f)
+}
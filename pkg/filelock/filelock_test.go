@@ -0,0 +1,33 @@
+package filelock
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thetool-filelock")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "lock")
+
+	f, err := Lock(filename, false)
+	if err != nil {
+		t.Fatal("unable to lock", err)
+	}
+	if _, err := Lock(filename, false); err != ErrLocked {
+		t.Errorf("expected the file to be locked, got %v", err)
+	}
+	if err := Unlock(f); err != nil {
+		t.Fatal("unable to unlock", err)
+	}
+	f, err = Lock(filename, false)
+	if err != nil {
+		t.Fatal("expected the file to be unlocked", err)
+	}
+	Unlock(f)
+}
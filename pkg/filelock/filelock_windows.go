@@ -0,0 +1,51 @@
+package filelock
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32         = windows.NewLazySystemDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+
+	errorLockViolation syscall.Errno = 33
+)
+
+// region is the byte range locked. It is past the content of the file, as
+// other processes can't read the bytes that are locked.
+func region() *windows.Overlapped {
+	return &windows.Overlapped{OffsetHigh: 1}
+}
+
+func lock(f *os.File, wait bool) error {
+	flags := uintptr(lockfileExclusiveLock)
+	if !wait {
+		flags |= lockfileFailImmediately
+	}
+	r, _, err := procLockFileEx.Call(f.Fd(), flags, 0, 1, 0, uintptr(unsafe.Pointer(region())))
+	if r == 0 {
+		if err == errorLockViolation || err == windows.ERROR_IO_PENDING {
+			return ErrLocked
+		}
+		return err
+	}
+	return nil
+}
+
+func unlock(f *os.File) error {
+	r, _, err := procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(region())))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
@@ -18,11 +18,6 @@ import (
 func Build(enabled []feature.Feature, verbose, dryRun, cache bool, sshKeyFile, glooRepo, glooHash, workDir string) error {
 	fmt.Println("Building Gloo...")
 
-	script := fmt.Sprintf(buildScript, workDir)
-	if err := ioutil.WriteFile("build-gloo.sh", []byte(script), 0755); err != nil {
-		return errors.Wrap(err, "unable to write build script")
-	}
-
 	if err := downloader.Download(glooRepo, glooHash, workDir, verbose); err != nil {
 		return errors.Wrap(err, "unable to download gloo repository")
 	}
@@ -33,16 +28,35 @@ func Build(enabled []feature.Feature, verbose, dryRun, cache bool, sshKeyFile, g
 	}
 
 	plugins := toGlooPlugins(enabled)
+	glooDir := filepath.Join(workDir, downloader.RepoDir(glooRepo))
+	if downloader.IsLocal(glooRepo) {
+		// build a copy so adding the plugins doesn't change the checkout
+		src, err := downloader.LocalPath(glooRepo)
+		if err != nil {
+			return errors.Wrapf(err, "invalid local gloo repository %s", glooRepo)
+		}
+		if err := os.RemoveAll(glooDir); err != nil {
+			return errors.Wrapf(err, "unable to remove %s", glooDir)
+		}
+		if err := util.CopyDir(src, glooDir); err != nil {
+			return errors.Wrapf(err, "unable to copy gloo from %s", src)
+		}
+	}
+	script := fmt.Sprintf(buildScript, common.SSHRewrites(pluginRepositories(glooRepo, plugins)),
+		filepath.ToSlash(glooDir), vendorLocal(plugins))
+	if err := ioutil.WriteFile("build-gloo.sh", []byte(script), 0755); err != nil {
+		return errors.Wrap(err, "unable to write build script")
+	}
 
 	fmt.Println("Adding plugins to Gloo...")
-	pf := filepath.Join(workDir, installFile)
+	pf := filepath.Join(glooDir, installFile)
 	if err := installPlugins(plugins,
 		pf, installTemplate); err != nil {
 		return errors.Wrapf(err, "unable to update %s", pf)
 	}
 
 	fmt.Println("Constraining plugins to given revisions...")
-	df := filepath.Join(workDir, dependencyFile)
+	df := filepath.Join(glooDir, dependencyFile)
 	if err := updateDep(plugins, df, glooRepo); err != nil {
 		return errors.Wrapf(err, "unable to update to dependencies file %s", df)
 	}
@@ -55,6 +69,7 @@ func Build(enabled []feature.Feature, verbose, dryRun, cache bool, sshKeyFile, g
 	}
 	name := "thetool-gloo"
 	args := []string{"run", "-i", "--rm", "--name", name, "-v", pwd + ":/gloo"}
+	args = append(args, localVolumes(plugins)...)
 	if cache {
 		gloocache := filepath.Join(pwd, "cache", "gloo")
 		// create it first to make sure it's with the current user.
@@ -63,9 +78,12 @@ func Build(enabled []feature.Feature, verbose, dryRun, cache bool, sshKeyFile, g
 		args = append(args, "-v", gloocache+":/go/pkg/dep/sources")
 	}
 
-	if sshKeyFile != "" {
-		args = append(args, common.GetSshKeyArgs(sshKeyFile)...)
+	cargs, cleanup, err := common.GetCredentialArgs(sshKeyFile, pluginRepositories(glooRepo, plugins))
+	if err != nil {
+		return err
 	}
+	defer cleanup()
+	args = append(args, cargs...)
 	uargs, err := common.GetUidArgs()
 	if err != nil {
 		// doesn't return current user in Jenkins
@@ -82,11 +100,11 @@ func Build(enabled []feature.Feature, verbose, dryRun, cache bool, sshKeyFile, g
 	return nil
 }
 
-func Publish(verbose, dryRun, publish bool, workDir, imageTag, user string) error {
+func Publish(verbose, dryRun, publish bool, glooRepo, workDir, imageTag, user string) error {
 	fmt.Println("Publishing Gloo...")
 
 	if !dryRun {
-		if err := util.Copy(filepath.Join(workDir, "gloo", "cmd", "control-plane", "Dockerfile"), filepath.Join("gloo-out", "Dockerfile")); err != nil {
+		if err := util.Copy(filepath.Join(workDir, downloader.RepoDir(glooRepo), "cmd", "control-plane", "Dockerfile"), filepath.Join("gloo-out", "Dockerfile")); err != nil {
 			return errors.Wrap(err, "not able to copy the Dockerfile")
 		}
 
@@ -132,10 +150,10 @@ func installPlugins(packages []GlooPlugin, filename string, t *template.Template
 
 func updateDep(plugins []GlooPlugin, filename, glooRepo string) error {
 	// get unique Repositories
-	repos := make(map[string]string)
+	repos := make(map[string]dependency)
 	for _, p := range plugins {
-		if p.Repository != glooRepo {
-			repos[getPackage(p.Repository)] = p.Revision
+		if p.Repository != glooRepo && !downloader.IsLocal(p.Repository) {
+			repos[getPackage(p.Repository)] = dependency{Revision: p.Revision, Source: dependencySource(p.Repository)}
 		}
 	}
 	var w bytes.Buffer
@@ -155,3 +173,31 @@ func updateDep(plugins []GlooPlugin, filename, glooRepo string) error {
 
 	return nil
 }
+
+// pluginRepositories are the repositories dep fetches the plugins from
+func pluginRepositories(glooRepo string, plugins []GlooPlugin) []string {
+	repos := []string{glooRepo}
+	for _, p := range plugins {
+		if !downloader.IsLocal(p.Repository) {
+			repos = append(repos, p.Repository)
+		}
+	}
+	return repos
+}
+
+// localVolumes mounts the local repositories of the plugins at the same path
+// in the build container
+func localVolumes(plugins []GlooPlugin) []string {
+	var args []string
+	seen := make(map[string]bool)
+	for _, p := range plugins {
+		if !downloader.IsLocal(p.Repository) || seen[p.Repository] {
+			continue
+		}
+		seen[p.Repository] = true
+		if src, err := downloader.LocalPath(p.Repository); err == nil {
+			args = append(args, "-v", src+":"+filepath.ToSlash(src)+":ro")
+		}
+	}
+	return args
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/solo-io/thetool/pkg/feature"
@@ -21,10 +22,19 @@ func TestUpdateDep(t *testing.T) {
 }
 
 func TestGetPackage(t *testing.T) {
+	defer os.Setenv("GOPATH", os.Getenv("GOPATH"))
+	os.Setenv("GOPATH", "/home/dev/go")
 	tests := [][]string{
 		{"https://github.com/solo-io/gloo-plugins.git", "github.com/solo-io/gloo-plugins"},
 		{"git@solo.io/test/as", "solo.io/test/as"},
 		{"git@solo.io/test2/chori.git", "solo.io/test2/chori"},
+		{"git@gitlab.example.com:group/sub/repo.git", "gitlab.example.com/group/sub/repo"},
+		{"ssh://dev@review.example.com:29418/plugins", "review.example.com/plugins"},
+		{"git://example.com/plugins.git", "example.com/plugins"},
+		{"/home/dev/go/src/github.com/axhixh/gloo-magic", "github.com/axhixh/gloo-magic"},
+		{"/home/dev/go/src/github.com/axhixh/gloo-magic/src/plugins", "github.com/axhixh/gloo-magic/src/plugins"},
+		{"/home/dev/src/gloo-magic", "local/gloo-magic"},
+		{"file:///work/gloo-magic", "local/gloo-magic"},
 	}
 
 	for _, entry := range tests {
@@ -98,3 +108,18 @@ func TestToGlooPlugins(t *testing.T) {
 		}
 	}
 }
+
+func TestVendorLocal(t *testing.T) {
+	plugins := []GlooPlugin{
+		{Package: "local/magic/aws", Repository: "/work/magic"},
+		{Package: "local/magic/nats", Repository: "/work/magic"},
+		{Package: "github.com/solo-io/gloo-plugins/aws", Repository: "https://github.com/solo-io/gloo-plugins.git"},
+	}
+	script := vendorLocal(plugins)
+	if strings.Count(script, "cp -r") != 1 {
+		t.Errorf("expected the local repository to be copied once got %q", script)
+	}
+	if !strings.Contains(script, "cp -r '/work/magic' 'vendor/local/magic'") {
+		t.Errorf("unexpected script %q", script)
+	}
+}
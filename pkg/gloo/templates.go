@@ -1,11 +1,14 @@
 package gloo
 
 import (
+	"bytes"
+	"fmt"
+	"path"
 	"path/filepath"
-	"strings"
 	"text/template"
 
 	"github.com/solo-io/thetool/pkg/common"
+	"github.com/solo-io/thetool/pkg/downloader"
 	"github.com/solo-io/thetool/pkg/feature"
 )
 
@@ -30,21 +33,20 @@ fi
 cat << EOF > build_user.sh
 #!/bin/bash
 set -ex
-
+` + common.CredentialsTemplate + `
 if [ -n "$GIT_SSH_COMMAND" ]; then
 	GIT_SSH_COMMAND="$GIT_SSH_COMMAND"
-	git config --global url.\"git@github.com:\".insteadOf \"https://github.com\"
-fi
+%sfi
 
 PATH="$PATH"
 cd $GOPATH
 mkdir -p -v src/github.com/solo-io
 cd src/github.com/solo-io
-ln -s /gloo/%s/gloo .
+ln -s /gloo/%s gloo
 cd gloo && pwd
 go get -u github.com/golang/dep/cmd/dep
 dep ensure -vendor-only
-make clean
+%smake clean
 make control-plane
 cp _output/control-plane /gloo/gloo-out
 
@@ -69,15 +71,18 @@ import (
 {{end}}
 )`
 
-	installFile = "gloo/internal/control-plane/install/install_plugins.go"
+	installFile = "internal/control-plane/install/install_plugins.go"
 
 	gopkg = `{{range $k, $v := .}}
 [[constraint]]
   name = "{{$k}}"
-  revision = "{{$v}}"
+  revision = "{{$v.Revision}}"
+{{- if $v.Source}}
+  source = "{{$v.Source}}"
+{{- end}}
 {{end}}`
 
-	dependencyFile = "gloo/Gopkg.toml"
+	dependencyFile = "Gopkg.toml"
 )
 
 var (
@@ -90,38 +95,88 @@ func init() {
 	packageTemplate = template.Must(template.New("package").Parse(gopkg))
 }
 
+// dependency is a constraint added to the Gopkg.toml of Gloo
+type dependency struct {
+	Revision string
+	Source   string
+}
+
 type GlooPlugin struct {
 	Package    string
 	Revision   string
 	Repository string
 }
 
+// toGlooPlugins returns the plugin packages to install; features sharing a
+// package are only imported once
 func toGlooPlugins(features []feature.Feature) []GlooPlugin {
 	plugins := []GlooPlugin{}
+	seen := make(map[string]bool)
 	for _, f := range features {
 		if f.GlooDir != "" {
 			p := GlooPlugin{
-				Package:    filepath.Join(getPackage(f.Repository), f.GlooDir),
+				Package:    PluginPackage(f),
 				Revision:   f.Revision,
 				Repository: f.Repository,
 			}
+			if seen[p.Package] {
+				continue
+			}
+			seen[p.Package] = true
 			plugins = append(plugins, p)
 		}
 	}
 	return plugins
 }
 
+// PluginPackage is the Go import path of the feature's Gloo plugin
+func PluginPackage(f feature.Feature) string {
+	return filepath.Join(getPackage(f.Repository), f.GlooDir)
+}
+
 func getPackage(repo string) string {
-	pluginPackage := strings.Replace(repo, "https://", "", 1)
-	pluginPackage = strings.Replace(pluginPackage, "http://", "", 1)
+	if downloader.IsLocal(repo) {
+		return downloader.LocalImportPath(repo)
+	}
+	u, err := downloader.ParseGitURL(repo)
+	if err != nil {
+		return repo
+	}
+	return u.ImportPath()
+}
 
-	atIndex := strings.Index(pluginPackage, "@")
-	if atIndex >= 0 {
-		pluginPackage = pluginPackage[atIndex+1:]
+// dependencySource is the URL dep fetches the repository from, or empty if
+// dep finds it from the import path. That only works for HTTPS URLs on the
+// hosts dep knows; private and self-hosted servers need the URL.
+func dependencySource(repo string) string {
+	u, err := downloader.ParseGitURL(repo)
+	if err != nil {
+		return ""
+	}
+	if u.Scheme == "https" && u.Port == "" && (u.Host == "github.com" || u.Host == "bitbucket.org") {
+		return ""
 	}
+	return u.CloneURL()
+}
 
-	if strings.HasSuffix(pluginPackage, ".git") {
-		pluginPackage = pluginPackage[:len(pluginPackage)-len(".git")]
+// vendorLocal copies the local repositories of the plugins to the vendor
+// directory of Gloo, as they can't be fetched by dep. The repositories are
+// mounted at the same path in the build container.
+func vendorLocal(plugins []GlooPlugin) string {
+	var b bytes.Buffer
+	seen := make(map[string]bool)
+	for _, p := range plugins {
+		if !downloader.IsLocal(p.Repository) || seen[p.Repository] {
+			continue
+		}
+		seen[p.Repository] = true
+		src, err := downloader.LocalPath(p.Repository)
+		if err != nil {
+			continue
+		}
+		dst := "vendor/" + getPackage(p.Repository)
+		fmt.Fprintf(&b, "rm -rf '%s'\nmkdir -p '%s'\ncp -r '%s' '%s'\nrm -rf '%s/vendor' '%s/.git'\n",
+			dst, path.Dir(dst), filepath.ToSlash(src), dst, dst, dst)
 	}
-	return pluginPackage
+	return b.String()
 }
@@ -0,0 +1,233 @@
+package lock
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/solo-io/thetool/pkg/addon"
+	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/feature"
+	"github.com/solo-io/thetool/pkg/workspace"
+)
+
+const (
+	// Filename is the name of the workspace lockfile
+	Filename = "thetool.lock"
+
+	sectionComponents   = "components"
+	sectionRepositories = "repositories"
+	sectionFeatures     = "features"
+	sectionAddons       = "addons"
+)
+
+// schema versions the lockfile
+var schema = workspace.Schema{Version: 1}
+
+// Components are the resolved versions of the core components
+type Components struct {
+	GlooRepo         string `json:"glooRepo"`
+	GlooHash         string `json:"glooHash"`
+	EnvoyRepoUser    string `json:"envoyRepoUser"`
+	EnvoyHash        string `json:"envoyHash"`
+	EnvoyCommonHash  string `json:"envoyCommonHash"`
+	EnvoyBuilderHash string `json:"envoyBuilderHash"`
+}
+
+// LockedFeature is an enabled feature pinned to the revision of its repository
+type LockedFeature struct {
+	Name       string `json:"name"`
+	Repository string `json:"repository"`
+	Revision   string `json:"revision"`
+}
+
+// Lock captures everything a build depends on. It doesn't contain any
+// timestamps so the same workspace always produces the same lockfile.
+type Lock struct {
+	SchemaVersion int                  `json:"schemaVersion"`
+	Components    Components           `json:"components"`
+	Repositories  []feature.Repository `json:"repositories"`
+	Features      []LockedFeature      `json:"features"`
+	Addons        []*addon.Addon       `json:"addons"`
+	Digests       map[string]string    `json:"digests"`
+}
+
+// Generate creates the lock for the given workspace content
+func Generate(conf *config.Config, repos []feature.Repository, features []feature.Feature, addons []*addon.Addon) (*Lock, error) {
+	l := &Lock{
+		SchemaVersion: schema.Version,
+		Components: Components{
+			GlooRepo:         conf.GlooRepo,
+			GlooHash:         conf.GlooHash,
+			EnvoyRepoUser:    conf.EnvoyRepoUser,
+			EnvoyHash:        conf.EnvoyHash,
+			EnvoyCommonHash:  conf.EnvoyCommonHash,
+			EnvoyBuilderHash: conf.EnvoyBuilderHash,
+		},
+		Repositories: append([]feature.Repository{}, repos...),
+		Features:     []LockedFeature{},
+		Addons:       append([]*addon.Addon{}, addons...),
+	}
+	sort.Slice(l.Repositories, func(i, j int) bool { return l.Repositories[i].URL < l.Repositories[j].URL })
+	for _, f := range features {
+		if f.Enabled {
+			l.Features = append(l.Features, LockedFeature{Name: f.Name, Repository: f.Repository, Revision: f.Revision})
+		}
+	}
+	sort.Slice(l.Features, func(i, j int) bool {
+		if l.Features[i].Repository != l.Features[j].Repository {
+			return l.Features[i].Repository < l.Features[j].Repository
+		}
+		return l.Features[i].Name < l.Features[j].Name
+	})
+	sort.Slice(l.Addons, func(i, j int) bool { return l.Addons[i].Name < l.Addons[j].Name })
+
+	var err error
+	l.Digests, err = l.digests()
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Lock) digests() (map[string]string, error) {
+	sections := map[string]interface{}{
+		sectionComponents:   l.Components,
+		sectionRepositories: l.Repositories,
+		sectionFeatures:     l.Features,
+		sectionAddons:       l.Addons,
+	}
+	digests := make(map[string]string)
+	for name, content := range sections {
+		b, err := json.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		digests[name] = fmt.Sprintf("sha256:%x", sha256.Sum256(b))
+	}
+	return digests, nil
+}
+
+// Drift lists the differences between the locked workspace and the current one
+func (l *Lock) Drift(current *Lock) []string {
+	var drift []string
+	if l.Digests[sectionComponents] != current.Digests[sectionComponents] {
+		drift = append(drift, componentDrift(l.Components, current.Components)...)
+	}
+	if l.Digests[sectionRepositories] != current.Digests[sectionRepositories] {
+		drift = append(drift, repositoryDrift(l.Repositories, current.Repositories)...)
+	}
+	if l.Digests[sectionFeatures] != current.Digests[sectionFeatures] {
+		drift = append(drift, featureDrift(l.Features, current.Features)...)
+	}
+	if l.Digests[sectionAddons] != current.Digests[sectionAddons] {
+		drift = append(drift, "addon configuration changed")
+	}
+	return drift
+}
+
+func componentDrift(locked, current Components) []string {
+	var drift []string
+	check := func(name, l, c string) {
+		if l != c {
+			drift = append(drift, fmt.Sprintf("%s changed from %s to %s", name, l, c))
+		}
+	}
+	check("Gloo repository", locked.GlooRepo, current.GlooRepo)
+	check("Gloo hash", locked.GlooHash, current.GlooHash)
+	check("Envoy repository user", locked.EnvoyRepoUser, current.EnvoyRepoUser)
+	check("Envoy hash", locked.EnvoyHash, current.EnvoyHash)
+	check("Envoy common hash", locked.EnvoyCommonHash, current.EnvoyCommonHash)
+	check("Envoy builder hash", locked.EnvoyBuilderHash, current.EnvoyBuilderHash)
+	return drift
+}
+
+func repositoryDrift(locked, current []feature.Repository) []string {
+	var drift []string
+	for _, l := range locked {
+		found := false
+		for _, c := range current {
+			if l.URL == c.URL {
+				found = true
+				if l.Commit != c.Commit {
+					drift = append(drift, fmt.Sprintf("repository %s changed from commit %s to %s", l.URL, l.Commit, c.Commit))
+				} else if l.SHA256 != c.SHA256 {
+					drift = append(drift, fmt.Sprintf("repository %s archive changed from sha256 %s to %s", l.URL, l.SHA256, c.SHA256))
+				} else if !reflect.DeepEqual(l, c) {
+					drift = append(drift, fmt.Sprintf("repository %s settings changed", l.URL))
+				}
+			}
+		}
+		if !found {
+			drift = append(drift, fmt.Sprintf("repository %s was removed", l.URL))
+		}
+	}
+	for _, c := range current {
+		found := false
+		for _, l := range locked {
+			found = found || l.URL == c.URL
+		}
+		if !found {
+			drift = append(drift, fmt.Sprintf("repository %s was added", c.URL))
+		}
+	}
+	return drift
+}
+
+func featureDrift(locked, current []LockedFeature) []string {
+	var drift []string
+	for _, l := range locked {
+		found := false
+		for _, c := range current {
+			if l.Name == c.Name && l.Repository == c.Repository {
+				found = true
+				if l.Revision != c.Revision {
+					drift = append(drift, fmt.Sprintf("feature %s changed from revision %s to %s", l.Name, l.Revision, c.Revision))
+				}
+			}
+		}
+		if !found {
+			drift = append(drift, fmt.Sprintf("feature %s is no longer enabled", l.Name))
+		}
+	}
+	for _, c := range current {
+		found := false
+		for _, l := range locked {
+			found = found || (l.Name == c.Name && l.Repository == c.Repository)
+		}
+		if !found {
+			drift = append(drift, fmt.Sprintf("feature %s is enabled but not locked", c.Name))
+		}
+	}
+	return drift
+}
+
+// Save writes the lock to a file
+func (l *Lock) Save(filename string) error {
+	b, err := json.MarshalIndent(l, "", " ")
+	if err != nil {
+		return err
+	}
+	return workspace.WriteFile(filename, append(b, '\n'), 0644)
+}
+
+// Load reads the lock from a file
+func Load(filename string) (*Lock, error) {
+	b, err := schema.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	l := &Lock{}
+	err = json.Unmarshal(b, l)
+	if err != nil {
+		return nil, err
+	}
+	// the digests in the file may be stale if it was edited or merged
+	l.Digests, err = l.digests()
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
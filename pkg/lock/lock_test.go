@@ -0,0 +1,118 @@
+package lock
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/solo-io/thetool/pkg/addon"
+	"github.com/solo-io/thetool/pkg/config"
+	"github.com/solo-io/thetool/pkg/feature"
+)
+
+func testWorkspace() (*config.Config, []feature.Repository, []feature.Feature, []*addon.Addon) {
+	conf := &config.Config{GlooRepo: config.GlooRepo, GlooHash: "g1", EnvoyHash: "e1"}
+	repos := []feature.Repository{
+		{URL: "https://github.com/solo-io/gloo.git", Commit: "g1"},
+		{URL: "https://github.com/axhixh/gloo-magic.git", Commit: "m1"},
+	}
+	features := []feature.Feature{
+		{Name: "magic", Repository: repos[1].URL, Revision: "m1", Enabled: true},
+		{Name: "aws_lambda", Repository: repos[0].URL, Revision: "g1", Enabled: true},
+		{Name: "nats", Repository: repos[0].URL, Revision: "g1", Enabled: false},
+	}
+	addons := []*addon.Addon{
+		{Name: "metrics", Configuration: map[string]interface{}{"status": "disable"}},
+		{Name: "function-discovery", Configuration: map[string]interface{}{"enable": true}},
+	}
+	return conf, repos, features, addons
+}
+
+func TestGenerateIsReproducible(t *testing.T) {
+	conf, repos, features, addons := testWorkspace()
+	first, err := Generate(conf, repos, features, addons)
+	if err != nil {
+		t.Fatal("unable to generate lock", err)
+	}
+	// same content in a different order
+	repos[0], repos[1] = repos[1], repos[0]
+	features[0], features[1] = features[1], features[0]
+	addons[0], addons[1] = addons[1], addons[0]
+	second, err := Generate(conf, repos, features, addons)
+	if err != nil {
+		t.Fatal("unable to generate lock", err)
+	}
+
+	a, _ := json.Marshal(first)
+	b, _ := json.Marshal(second)
+	if string(a) != string(b) {
+		t.Errorf("expected identical locks got\n%s\n%s", a, b)
+	}
+	if len(first.Features) != 2 {
+		t.Errorf("expected only enabled features to be locked got %v", first.Features)
+	}
+	if drift := first.Drift(second); len(drift) != 0 {
+		t.Errorf("expected no drift got %v", drift)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	conf, repos, features, addons := testWorkspace()
+	locked, err := Generate(conf, repos, features, addons)
+	if err != nil {
+		t.Fatal("unable to generate lock", err)
+	}
+
+	conf.GlooHash = "g2"
+	repos[1].Commit = "m2"
+	features[2].Enabled = true
+	addons[0].Configuration["status"] = "all"
+	current, err := Generate(conf, repos, features, addons)
+	if err != nil {
+		t.Fatal("unable to generate lock", err)
+	}
+
+	drift := strings.Join(locked.Drift(current), "\n")
+	for _, expected := range []string{
+		"Gloo hash changed from g1 to g2",
+		"repository https://github.com/axhixh/gloo-magic.git changed from commit m1 to m2",
+		"feature nats is enabled but not locked",
+		"addon configuration changed",
+	} {
+		if !strings.Contains(drift, expected) {
+			t.Errorf("expected %q in drift:\n%s", expected, drift)
+		}
+	}
+}
+
+func TestLoadIgnoresStaleDigests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thetool-lock")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	defer os.RemoveAll(dir)
+	conf, repos, features, addons := testWorkspace()
+	locked, err := Generate(conf, repos, features, addons)
+	if err != nil {
+		t.Fatal("unable to generate lock", err)
+	}
+	filename := filepath.Join(dir, Filename)
+	if err := locked.Save(filename); err != nil {
+		t.Fatal("unable to save lock", err)
+	}
+
+	// a commit changed by hand or by a merge, leaving the digests alone
+	b, _ := ioutil.ReadFile(filename)
+	ioutil.WriteFile(filename, []byte(strings.Replace(string(b), `"commit": "m1"`, `"commit": "m2"`, 1)), 0644)
+	edited, err := Load(filename)
+	if err != nil {
+		t.Fatal("unable to load lock", err)
+	}
+	drift := edited.Drift(locked)
+	if len(drift) != 1 || !strings.Contains(drift[0], "changed from commit m2 to m1") {
+		t.Errorf("expected the edited commit to drift, got %v", drift)
+	}
+}
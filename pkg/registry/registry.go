@@ -0,0 +1,189 @@
+// Package registry reads feature registry indexes: JSON documents listing
+// feature repositories, their features and the commits recommended for
+// them. An index is read from a file or from any HTTP server, so a static
+// file server is enough to share one without access to the internet.
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/auth"
+	"github.com/solo-io/thetool/pkg/feature"
+)
+
+const (
+	// Env is the location of the index used when none is given
+	Env = "THETOOL_REGISTRY"
+	// SchemaVersion is the newest version of the index this version of
+	// thetool reads
+	SchemaVersion = 1
+)
+
+// Index lists the repositories of a registry
+type Index struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	Repositories  []Repository `json:"repositories"`
+}
+
+// Repository is a feature repository in the index, with the settings to add
+// it with
+type Repository struct {
+	URL   string `json:"url"`
+	Alias string `json:"alias,omitempty"`
+	// Commit is the recommended branch, tag or commit
+	Commit      string    `json:"commit"`
+	Manifest    string    `json:"manifest,omitempty"`
+	Subdir      string    `json:"subdir,omitempty"`
+	SHA256      string    `json:"sha256,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Features    []Feature `json:"features"`
+}
+
+// ID is the alias the features of the repository are qualified with
+func (r Repository) ID() string {
+	if r.Alias != "" {
+		return r.Alias
+	}
+	return feature.DefaultAlias(r.URL)
+}
+
+// Feature is a feature of a repository in the index
+type Feature struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Match is a feature found in the index
+type Match struct {
+	Repository Repository
+	Feature    Feature
+}
+
+// ID is the qualified name of the feature, alias/name
+func (m Match) ID() string {
+	return m.Repository.ID() + "/" + m.Feature.Name
+}
+
+// Load reads the index from an http(s) URL, a file:// URL or a file path.
+// Requests are authorized with the credentials for the URL.
+func Load(location string) (*Index, error) {
+	var b []byte
+	var err error
+	switch {
+	case strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"):
+		b, err = download(location)
+	default:
+		b, err = ioutil.ReadFile(strings.TrimPrefix(location, "file://"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+func download(url string) ([]byte, error) {
+	name := auth.Redact(url)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid URL "+name)
+	}
+	auth.Authorize(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.New("unable to download " + name + ": " + auth.Redact(err.Error()))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unable to download %s: %s", name, resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	return b, errors.Wrap(err, "unable to download "+name)
+}
+
+// Parse decodes and checks an index
+func Parse(b []byte) (*Index, error) {
+	ix := &Index{}
+	if err := json.Unmarshal(b, ix); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the registry index")
+	}
+	if ix.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("registry index was written for a newer version of thetool (schema version %d, this version supports up to %d); please upgrade thetool",
+			ix.SchemaVersion, SchemaVersion)
+	}
+	aliases := make(map[string]string)
+	for _, r := range ix.Repositories {
+		if r.URL == "" || r.Commit == "" {
+			return nil, fmt.Errorf("registry index lists a repository without a URL or commit")
+		}
+		if other, ok := aliases[r.ID()]; ok {
+			return nil, fmt.Errorf("repositories %s and %s of the registry index have the same alias %s", other, r.URL, r.ID())
+		}
+		aliases[r.ID()] = r.URL
+		names := make(map[string]bool)
+		for _, f := range r.Features {
+			if f.Name == "" || names[f.Name] {
+				return nil, fmt.Errorf("repository %s of the registry index has a feature without a name or with a duplicate name", r.URL)
+			}
+			names[f.Name] = true
+		}
+	}
+	return ix, nil
+}
+
+// Search returns the features whose name, tags or description, or the
+// alias, URL or description of their repository, contain the term, ignoring
+// case. An empty term matches all the features. Matches are sorted by their
+// qualified name.
+func (ix *Index) Search(term string) []Match {
+	term = strings.ToLower(term)
+	var matches []Match
+	for _, r := range ix.Repositories {
+		for _, f := range r.Features {
+			fields := append([]string{f.Name, f.Description, r.ID(), r.URL, r.Description}, f.Tags...)
+			for _, s := range fields {
+				if strings.Contains(strings.ToLower(s), term) {
+					matches = append(matches, Match{Repository: r, Feature: f})
+					break
+				}
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID() < matches[j].ID() })
+	return matches
+}
+
+// Find returns the feature with the given qualified name (alias/name) or
+// unambiguous short name
+func (ix *Index) Find(name string) (Match, error) {
+	var matches []Match
+	for _, r := range ix.Repositories {
+		for _, f := range r.Features {
+			m := Match{Repository: r, Feature: f}
+			if m.ID() == name {
+				return m, nil
+			}
+			if f.Name == name {
+				matches = append(matches, m)
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Match{}, fmt.Errorf("unable to find feature %s in the registry", name)
+	case 1:
+		return matches[0], nil
+	default:
+		ids := make([]string, len(matches))
+		for i, m := range matches {
+			ids[i] = m.ID()
+		}
+		return Match{}, fmt.Errorf("feature name %s is ambiguous in the registry; use one of %s", name, strings.Join(ids, ", "))
+	}
+}
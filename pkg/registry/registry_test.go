@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	ix, err := Load("testdata/index.json")
+	if err != nil {
+		t.Fatal("unable to load the index", err)
+	}
+	cases := []struct {
+		term     string
+		expected []string
+	}{
+		{"", []string{"gloo/aws_lambda", "gloo/nats", "platform/audit", "platform/aws_lambda"}},
+		{"AWS", []string{"gloo/aws_lambda", "platform/aws_lambda"}},
+		{"messaging", []string{"gloo/nats"}},
+		{"every request", []string{"platform/audit"}},
+		{"git.example.com", []string{"platform/audit", "platform/aws_lambda"}},
+		{"grpc", nil},
+	}
+	for _, c := range cases {
+		var ids []string
+		for _, m := range ix.Search(c.term) {
+			ids = append(ids, m.ID())
+		}
+		if strings.Join(ids, ",") != strings.Join(c.expected, ",") {
+			t.Errorf("expected %q to find %v got %v", c.term, c.expected, ids)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	ix, err := Load("file://testdata/index.json")
+	if err != nil {
+		t.Fatal("unable to load the index", err)
+	}
+	m, err := ix.Find("audit")
+	if err != nil {
+		t.Fatal("expected to find the feature by its name", err)
+	}
+	if m.Repository.Commit != "release-1.0" || m.Repository.Subdir != "thetool" {
+		t.Errorf("expected the settings of the repository got %+v", m.Repository)
+	}
+	if _, err := ix.Find("aws_lambda"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Error("expected a name provided by two repositories to be ambiguous", err)
+	}
+	if m, err := ix.Find("gloo/aws_lambda"); err != nil || m.Repository.Commit != "v0.2.1" {
+		t.Error("expected to find the feature by its qualified name", err)
+	}
+	if _, err := ix.Find("gloo/grpc"); err == nil {
+		t.Error("expected an unknown feature not to be found")
+	}
+}
+
+func TestLoadHTTP(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/index.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer registry-token" {
+			http.Error(w, "denied", http.StatusUnauthorized)
+			return
+		}
+		w.Write(b)
+	}))
+	defer server.Close()
+
+	if _, err := Load(server.URL + "/index.json"); err == nil {
+		t.Error("expected the request to be denied without a token")
+	}
+	os.Setenv("THETOOL_TOKEN_127_0_0_1", "registry-token")
+	defer os.Unsetenv("THETOOL_TOKEN_127_0_0_1")
+	ix, err := Load(server.URL + "/index.json")
+	if err != nil {
+		t.Fatal("expected the token to be sent", err)
+	}
+	if len(ix.Repositories) != 2 {
+		t.Errorf("expected 2 repositories got %d", len(ix.Repositories))
+	}
+}
+
+func TestParse(t *testing.T) {
+	invalid := []string{
+		`{"schemaVersion": 2, "repositories": []}`,
+		`{"repositories": [{"url": "https://example.com/a.git", "features": []}]}`,
+		`{"repositories": [{"url": "https://example.com/a.git", "commit": "v1", "features": [{"name": "x"}, {"name": "x"}]}]}`,
+		`{"repositories": [{"url": "https://example.com/a.git", "commit": "v1"}, {"url": "https://example.org/a.git", "commit": "v1"}]}`,
+	}
+	for _, s := range invalid {
+		if _, err := Parse([]byte(s)); err == nil {
+			t.Errorf("expected %s to be invalid", s)
+		}
+	}
+}
@@ -8,11 +8,14 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 
 	"golang.org/x/net/context"
 
 	"github.com/pkg/errors"
+	"github.com/solo-io/thetool/pkg/auth"
 )
 
 func RunCmd(verbose, dryRun bool, binary string, args ...string) error {
@@ -30,7 +33,7 @@ func RunCmdCaptureOut(verbose, dryRun bool, binary string, args ...string) (*byt
 
 func RunCmdContext(ctx context.Context, verbose, dryRun bool, w io.Writer, binary string, args ...string) error {
 	if verbose {
-		fmt.Println(binary, args)
+		fmt.Print(auth.Redact(fmt.Sprintln(binary, args)))
 	}
 	if dryRun {
 		return nil
@@ -62,7 +65,7 @@ func RunCmdContext(ctx context.Context, verbose, dryRun bool, w io.Writer, binar
 		go func() {
 			prefix := binary + ": "
 			for stderrScanner.Scan() {
-				fmt.Println(prefix, stderrScanner.Text())
+				fmt.Println(prefix, auth.Redact(stderrScanner.Text()))
 			}
 		}()
 	}
@@ -79,9 +82,8 @@ func RunCmdContext(ctx context.Context, verbose, dryRun bool, w io.Writer, binar
 
 func DockerRun(verbose, dryRun bool, containerName string, args ...string) error {
 	ctx, cancel := dockerContext(containerName)
-	if dryRun {
-		defer cancel()
-	}
+	// stops watching for signals once docker is done
+	defer cancel()
 	return RunCmdContext(ctx, verbose, dryRun, os.Stdout, "docker", args...)
 }
 
@@ -90,6 +92,7 @@ func dockerContext(containerName string) (context.Context, context.CancelFunc) {
 	go func(name string) {
 		signalCh := make(chan os.Signal, 1)
 		signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(signalCh)
 
 		select {
 		case <-signalCh:
@@ -125,3 +128,37 @@ func Copy(src, dst string) error {
 	_, err = io.Copy(to, from)
 	return err
 }
+
+// CopyDir copies the directory tree to dst, leaving out hidden directories
+// like .git. Symbolic links are copied as links.
+func CopyDir(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			if p != src && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			if err := Copy(p, target); err != nil {
+				return err
+			}
+			return os.Chmod(target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,210 @@
+// Package watch polls directories for changed files and tells which
+// components need to be rebuilt for them
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/solo-io/thetool/pkg/downloader"
+	"github.com/solo-io/thetool/pkg/feature"
+)
+
+// Target is a directory whose changes are built by a component
+type Target struct {
+	Dir       string
+	Component string
+}
+
+// Targets maps the source directories of the enabled features, and of Gloo
+// if it is a local checkout, to the components built from them
+func Targets(workDir, glooRepo string, enabled []feature.Feature) []Target {
+	var targets []Target
+	if downloader.IsLocal(glooRepo) {
+		targets = append(targets, Target{Dir: downloader.SourceDir(workDir, glooRepo), Component: "gloo"})
+	}
+	for _, f := range enabled {
+		source := f.Root
+		if source == "" {
+			source = downloader.SourceDir(workDir, f.Repository)
+		}
+		if f.GlooDir != "" {
+			targets = append(targets, Target{Dir: filepath.Join(source, f.GlooDir), Component: "gloo"})
+		}
+		if f.EnvoyDir != "" {
+			targets = append(targets, Target{Dir: filepath.Join(source, f.EnvoyDir), Component: "envoy"})
+		}
+	}
+	return targets
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	mode    os.FileMode
+}
+
+// Watcher polls a set of directories. Polling works the same on every
+// platform and inside directories shared with Docker containers.
+type Watcher struct {
+	// Interval is the time between two polls
+	Interval time.Duration
+	// Debounce is how long the directories need to be quiet before a burst
+	// of changes is reported
+	Debounce time.Duration
+
+	dirs  []string
+	files map[string]fileState
+}
+
+// New creates a watcher and records the current state of the directories
+func New(dirs []string, interval, debounce time.Duration) (*Watcher, error) {
+	w := &Watcher{Interval: interval, Debounce: debounce, dirs: dirs}
+	files, err := w.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	w.files = files
+	return w, nil
+}
+
+// Wait blocks until files changed and no more changes were seen for the
+// debounce period. It returns the changed files, including removed ones, or
+// nothing once stop is closed.
+func (w *Watcher) Wait(stop <-chan struct{}) ([]string, error) {
+	changed := make(map[string]bool)
+	var last time.Time
+	for {
+		select {
+		case <-stop:
+			return nil, nil
+		case <-time.After(w.Interval):
+		}
+		files, err := w.snapshot()
+		if err != nil {
+			return nil, err
+		}
+		diff := compare(w.files, files)
+		w.files = files
+		for _, f := range diff {
+			changed[f] = true
+		}
+		if len(diff) != 0 {
+			last = time.Now()
+		}
+		if len(changed) != 0 && time.Since(last) >= w.Debounce {
+			break
+		}
+	}
+	list := make([]string, 0, len(changed))
+	for f := range changed {
+		list = append(list, f)
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+func (w *Watcher) snapshot() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	for _, dir := range w.dirs {
+		err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				// files may go away while we look at them
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if info.IsDir() {
+				// dependencies are vendored in bulk, not edited while
+				// developing, and a Gloo checkout vendors many files
+				if p != dir && (strings.HasPrefix(info.Name(), ".") || info.Name() == "vendor") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			files[p] = fileState{modTime: info.ModTime(), size: info.Size(), mode: info.Mode()}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func compare(old, current map[string]fileState) []string {
+	var changed []string
+	for p, s := range current {
+		if o, ok := old[p]; !ok || o != s {
+			changed = append(changed, p)
+		}
+	}
+	for p := range old {
+		if _, ok := current[p]; !ok {
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+// Affected returns the components that build the changed files, in the
+// order of the targets. A file belongs to the target with the most specific
+// directory, so an Envoy filter inside the directory of a Gloo plugin only
+// rebuilds Envoy.
+func Affected(targets []Target, files []string) []string {
+	affected := make(map[string]bool)
+	for _, f := range files {
+		best := -1
+		for i, t := range targets {
+			if within(t.Dir, f) && (best == -1 || len(t.Dir) > len(targets[best].Dir)) {
+				best = i
+			}
+		}
+		if best != -1 {
+			affected[targets[best].Component] = true
+		}
+	}
+	var components []string
+	for _, t := range targets {
+		if affected[t.Component] {
+			components = append(components, t.Component)
+			delete(affected, t.Component)
+		}
+	}
+	return components
+}
+
+func within(dir, file string) bool {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Dirs returns the directories to poll for the targets, leaving out the
+// ones inside another target directory
+func Dirs(targets []Target) []string {
+	var dirs []string
+	for _, t := range targets {
+		nested := false
+		for _, o := range targets {
+			if o.Dir != t.Dir && within(o.Dir, t.Dir) {
+				nested = true
+				break
+			}
+		}
+		seen := false
+		for _, d := range dirs {
+			seen = seen || d == t.Dir
+		}
+		if !nested && !seen {
+			dirs = append(dirs, t.Dir)
+		}
+	}
+	return dirs
+}
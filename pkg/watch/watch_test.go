@@ -0,0 +1,130 @@
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/solo-io/thetool/pkg/feature"
+)
+
+func TestWait(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thetool-watch")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	defer os.RemoveAll(dir)
+	removed := filepath.Join(dir, "removed.go")
+	if err := ioutil.WriteFile(removed, []byte("package x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Mkdir(filepath.Join(dir, ".git"), 0755)
+	os.Mkdir(filepath.Join(dir, "vendor"), 0755)
+
+	w, err := New([]string{dir}, 10*time.Millisecond, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal("unable to watch directory", err)
+	}
+	// a burst of edits is reported once it is over
+	go func() {
+		for i := 0; i < 5; i++ {
+			ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(time.Now().String()), 0644)
+			time.Sleep(20 * time.Millisecond)
+		}
+		os.Remove(removed)
+		ioutil.WriteFile(filepath.Join(dir, ".git", "index"), nil, 0644)
+		ioutil.WriteFile(filepath.Join(dir, "vendor", "dep.go"), nil, 0644)
+	}()
+	start := time.Now()
+	changed, err := w.Wait(nil)
+	if err != nil {
+		t.Fatal("unable to wait for changes", err)
+	}
+	expected := []string{filepath.Join(dir, "a.go"), removed}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Errorf("expected %v to change, got %v", expected, changed)
+	}
+	if d := time.Since(start); d < 180*time.Millisecond {
+		t.Errorf("changes reported after %v, before the burst was over", d)
+	}
+}
+
+func TestWaitStop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thetool-watch")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	defer os.RemoveAll(dir)
+	w, err := New([]string{dir}, 10*time.Millisecond, 0)
+	if err != nil {
+		t.Fatal("unable to watch directory", err)
+	}
+	stop := make(chan struct{})
+	close(stop)
+	if changed, err := w.Wait(stop); changed != nil || err != nil {
+		t.Errorf("expected nothing once stopped, got %v %v", changed, err)
+	}
+}
+
+func TestAffected(t *testing.T) {
+	targets := []Target{
+		{Dir: "/repo/aws", Component: "gloo"},
+		{Dir: "/repo/aws/envoy", Component: "envoy"},
+		{Dir: "/repo/nats", Component: "gloo"},
+	}
+	tests := []struct {
+		files    []string
+		expected []string
+	}{
+		{[]string{"/repo/aws/plugin.go"}, []string{"gloo"}},
+		{[]string{"/repo/aws/envoy/filter.cc"}, []string{"envoy"}},
+		{[]string{"/repo/aws/envoy/filter.cc", "/repo/nats/plugin.go"}, []string{"gloo", "envoy"}},
+		{[]string{"/repo/awsx/plugin.go", "/repo/README.md"}, nil},
+	}
+	for _, test := range tests {
+		actual := Affected(targets, test.files)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%v: expected %v, got %v", test.files, test.expected, actual)
+		}
+	}
+}
+
+func TestDirs(t *testing.T) {
+	targets := []Target{
+		{Dir: "/repo/aws", Component: "gloo"},
+		{Dir: "/repo/aws/envoy", Component: "envoy"},
+		{Dir: "/repo/nats", Component: "gloo"},
+		{Dir: "/repo/nats", Component: "envoy"},
+	}
+	expected := []string{"/repo/aws", "/repo/nats"}
+	if actual := Dirs(targets); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestTargets(t *testing.T) {
+	enabled := []feature.Feature{
+		{Name: "aws", GlooDir: "aws", EnvoyDir: "aws/envoy", Repository: "https://github.com/solo-io/gloo-plugins.git", Root: "/work/plugins"},
+		{Name: "nats", GlooDir: "nats", Repository: "/src/nats"},
+	}
+	expected := []Target{
+		{Dir: filepath.Join("/work/plugins", "aws"), Component: "gloo"},
+		{Dir: filepath.Join("/work/plugins", "aws/envoy"), Component: "envoy"},
+		{Dir: filepath.Join("/src/nats", "nats"), Component: "gloo"},
+	}
+	if actual := Targets("repositories", "https://github.com/solo-io/gloo.git", enabled); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	// a local Gloo checkout is watched as a whole
+	actual := Targets("repositories", "/src/gloo", enabled)
+	if len(actual) != 4 || actual[0] != (Target{Dir: filepath.Clean("/src/gloo"), Component: "gloo"}) {
+		t.Errorf("expected the local gloo checkout to be rebuilt by gloo, got %v", actual)
+	}
+	if affected := Affected(actual, []string{filepath.Join("/src/gloo", "pkg", "plugin.go")}); !reflect.DeepEqual(affected, []string{"gloo"}) {
+		t.Errorf("expected a change in the gloo checkout to rebuild gloo, got %v", affected)
+	}
+}
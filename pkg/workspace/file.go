@@ -0,0 +1,91 @@
+package workspace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// BackupSuffix is appended to the name of a file to get the name of
+	// the backup of its previous version
+	BackupSuffix = ".bak"
+)
+
+// WriteFile replaces the content of the file atomically. The data is written
+// to a temporary file in the same directory, synced to disk and renamed over
+// the file, so readers and crashes only ever see the old or the new content.
+// The previous version of the file is kept with the backup suffix.
+func WriteFile(filename string, data []byte, perm os.FileMode) error {
+	return writeFile(filename, data, perm, true)
+}
+
+func writeFile(filename string, data []byte, perm os.FileMode, backup bool) error {
+	dir := filepath.Dir(filename)
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(filename)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "unable to create temporary file for %s", filename)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "unable to write %s", tmp.Name())
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "unable to sync %s", tmp.Name())
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "unable to close %s", tmp.Name())
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return errors.Wrapf(err, "unable to set permissions on %s", tmp.Name())
+	}
+
+	if backup {
+		if err := Backup(filename); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return errors.Wrapf(err, "unable to replace %s", filename)
+	}
+	syncDir(dir)
+	return nil
+}
+
+// Backup keeps a copy of the current version of the file with the backup
+// suffix. It does nothing if the file doesn't exist.
+func Backup(filename string) error {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "unable to read %s for backup", filename)
+	}
+	backup := filename + BackupSuffix
+	tmp := backup + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return errors.Wrapf(err, "unable to write backup of %s", filename)
+	}
+	if err := os.Rename(tmp, backup); err != nil {
+		os.Remove(tmp)
+		return errors.Wrapf(err, "unable to write backup of %s", filename)
+	}
+	return nil
+}
+
+// syncDir makes the rename durable; not all platforms support syncing a
+// directory so errors are ignored
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	d.Sync()
+}
@@ -0,0 +1,94 @@
+package workspace
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/solo-io/thetool/pkg/filelock"
+)
+
+const (
+	// LockFileName is the file used to make sure only one thetool process
+	// changes the workspace at a time
+	LockFileName = ".thetool-lock"
+
+	// ReadOnlyAnnotation marks commands that don't change the workspace and
+	// so don't need the workspace lock
+	ReadOnlyAnnotation = "thetool.solo.io/read-only"
+)
+
+// Lock is an exclusive lock on a workspace held by this process
+type Lock struct {
+	file *os.File
+	dir  string
+}
+
+var (
+	heldMu sync.Mutex
+	// held are the directories of the workspaces this process holds the
+	// lock of
+	held = make(map[string]bool)
+)
+
+// Acquire locks the workspace in the given directory. It doesn't wait for
+// another process holding the lock but fails right away.
+func Acquire(dir string) (*Lock, error) {
+	filename := filepath.Join(dir, LockFileName)
+	f, err := filelock.Lock(filename, false)
+	if err != nil {
+		if err == filelock.ErrLocked {
+			return nil, fmt.Errorf("workspace is locked by another thetool process%s; please try again once it is done",
+				lockOwner(filename))
+		}
+		return nil, err
+	}
+	// record the owner to help figure out who holds the lock
+	f.Truncate(0)
+	f.WriteString(strconv.Itoa(os.Getpid()))
+	f.Sync()
+	l := &Lock{file: f, dir: absDir(dir)}
+	heldMu.Lock()
+	held[l.dir] = true
+	heldMu.Unlock()
+	return l, nil
+}
+
+// Release unlocks the workspace
+func (l *Lock) Release() error {
+	heldMu.Lock()
+	delete(held, l.dir)
+	heldMu.Unlock()
+	return filelock.Unlock(l.file)
+}
+
+// Held checks if this process holds the lock of the workspace in the
+// directory
+func Held(dir string) bool {
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	return held[absDir(dir)]
+}
+
+func absDir(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return filepath.Clean(dir)
+}
+
+func lockOwner(filename string) string {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+	pid := strings.TrimSpace(string(b))
+	if pid == "" {
+		return ""
+	}
+	return " (pid " + pid + ")"
+}
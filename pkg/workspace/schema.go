@@ -0,0 +1,106 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// VersionField is the field holding the schema version of a workspace file
+	VersionField = "schemaVersion"
+)
+
+// Migration changes a decoded workspace file from one schema version to the
+// next one
+type Migration func(doc map[string]interface{}) error
+
+// Schema describes the versions of a workspace file. Files written before
+// workspace files were versioned have version 0.
+type Schema struct {
+	// Version is the schema version written by this version of thetool
+	Version int
+	// Migrations maps a version to the migration to the next version.
+	// Versions that only add fields don't need one.
+	Migrations map[int]Migration
+}
+
+// Migrate upgrades the decoded file to the current version. It returns the
+// version the file had and fails if the file was written by a newer version
+// of thetool.
+func (s Schema) Migrate(doc map[string]interface{}) (int, error) {
+	from := 0
+	if v, ok := doc[VersionField]; ok {
+		switch n := v.(type) {
+		case float64:
+			from = int(n)
+			if float64(from) != n {
+				from = -1
+			}
+		case int:
+			from = n
+		default:
+			from = -1
+		}
+		if from < 0 {
+			return 0, fmt.Errorf("invalid %s %v", VersionField, v)
+		}
+	}
+	if from > s.Version {
+		return from, fmt.Errorf("file was written by a newer version of thetool (schema version %d, this version supports up to %d); please upgrade thetool",
+			from, s.Version)
+	}
+	for v := from; v < s.Version; v++ {
+		if m := s.Migrations[v]; m != nil {
+			if err := m(doc); err != nil {
+				return from, errors.Wrapf(err, "unable to migrate from schema version %d", v)
+			}
+		}
+	}
+	doc[VersionField] = s.Version
+	return from, nil
+}
+
+// ReadFile reads a workspace file and migrates it to the current version.
+// When this process holds the lock of the workspace, a migrated file is
+// written back in place and the original is kept with its version and the
+// backup suffix, for example features.json.v0.bak. Otherwise the file is
+// only migrated in memory; the next command changing the workspace saves it.
+func (s Schema) ReadFile(filename string) ([]byte, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	doc := make(map[string]interface{})
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse %s", filename)
+	}
+	from, err := s.Migrate(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, filename)
+	}
+	if from == s.Version {
+		return b, nil
+	}
+	if !Held(filepath.Dir(filename)) {
+		return json.Marshal(doc)
+	}
+
+	migrated, err := json.MarshalIndent(doc, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	backup := fmt.Sprintf("%s.v%d%s", filename, from, BackupSuffix)
+	if err := writeFile(backup, b, 0644, false); err != nil {
+		return nil, errors.Wrapf(err, "unable to back up %s before migrating it", filename)
+	}
+	if err := WriteFile(filename, migrated, 0644); err != nil {
+		return nil, errors.Wrapf(err, "unable to save migrated %s", filename)
+	}
+	fmt.Printf("Migrated %s from schema version %d to %d; the previous version is in %s\n",
+		filename, from, s.Version, backup)
+	return migrated, nil
+}
@@ -0,0 +1,134 @@
+package workspace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "thetool-workspace")
+	if err != nil {
+		t.Fatal("unable to create temporary directory", err)
+	}
+	return dir
+}
+
+func TestAcquire(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	l, err := Acquire(dir)
+	if err != nil {
+		t.Fatal("unable to lock workspace", err)
+	}
+	_, err = Acquire(dir)
+	if err == nil {
+		t.Fatal("expected workspace to be locked")
+	}
+	if !strings.Contains(err.Error(), "locked by another thetool process") {
+		t.Errorf("unexpected error %q", err)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatal("unable to release workspace lock", err)
+	}
+	l, err = Acquire(dir)
+	if err != nil {
+		t.Fatal("expected workspace to be unlocked", err)
+	}
+	l.Release()
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "features.json")
+
+	if err := WriteFile(filename, []byte("first"), 0644); err != nil {
+		t.Fatal("unable to write file", err)
+	}
+	if _, err := os.Stat(filename + BackupSuffix); !os.IsNotExist(err) {
+		t.Error("expected no backup for a new file")
+	}
+	if err := WriteFile(filename, []byte("second"), 0644); err != nil {
+		t.Fatal("unable to write file", err)
+	}
+
+	b, _ := ioutil.ReadFile(filename)
+	if string(b) != "second" {
+		t.Errorf("expected new content got %q", b)
+	}
+	b, _ = ioutil.ReadFile(filename + BackupSuffix)
+	if string(b) != "first" {
+		t.Errorf("expected previous content in backup got %q", b)
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("expected only the file and its backup got %d files", len(files))
+	}
+}
+
+func TestSchemaReadFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "features.json")
+	schema := Schema{
+		Version: 2,
+		Migrations: map[int]Migration{
+			1: func(doc map[string]interface{}) error {
+				doc["name"] = doc["Name"]
+				delete(doc, "Name")
+				return nil
+			},
+		},
+	}
+
+	old := `{"Name": "aws_lambda"}`
+	if err := ioutil.WriteFile(filename, []byte(old), 0644); err != nil {
+		t.Fatal("unable to write file", err)
+	}
+	b, err := schema.ReadFile(filename)
+	if err != nil {
+		t.Fatal("unable to migrate file", err)
+	}
+	if !strings.Contains(string(b), `"name":"aws_lambda"`) {
+		t.Errorf("expected the file to be migrated in memory got %s", b)
+	}
+	if saved, _ := ioutil.ReadFile(filename); string(saved) != old {
+		t.Errorf("expected the file to be left alone without the workspace lock got %s", saved)
+	}
+
+	l, err := Acquire(dir)
+	if err != nil {
+		t.Fatal("unable to lock the workspace", err)
+	}
+	defer l.Release()
+	b, err = schema.ReadFile(filename)
+	if err != nil {
+		t.Fatal("unable to migrate file", err)
+	}
+	saved, _ := ioutil.ReadFile(filename)
+	if string(saved) != string(b) {
+		t.Errorf("expected migrated file to be saved got %s", saved)
+	}
+	for _, expected := range []string{`"name": "aws_lambda"`, `"schemaVersion": 2`} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected %s in migrated file %s", expected, b)
+		}
+	}
+	backup, _ := ioutil.ReadFile(filename + ".v0" + BackupSuffix)
+	if string(backup) != old {
+		t.Errorf("expected original file in backup got %q", backup)
+	}
+
+	if err := ioutil.WriteFile(filename, []byte(`{"schemaVersion": 3}`), 0644); err != nil {
+		t.Fatal("unable to write file", err)
+	}
+	_, err = schema.ReadFile(filename)
+	if err == nil || !strings.Contains(err.Error(), "newer version of thetool") {
+		t.Errorf("expected newer file to be refused got %v", err)
+	}
+}